@@ -0,0 +1,65 @@
+package main
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// anomalyWindow is one ANOMALY_SCHEDULE entry: a kind of anomaly that runs
+// for duration at the start of every period. Periods are aligned to the Unix
+// epoch, so 15m/1m fires at :00, :15, :30 and :45 of every hour no matter
+// when the process started, and alerts can be expected at known times.
+type anomalyWindow struct {
+	every    time.Duration
+	duration time.Duration
+	kind     string
+}
+
+var anomalyKinds = []string{"latency", "errors"}
+
+func (a anomalyWindow) activeAt(t time.Time) bool {
+	return time.Duration(t.UnixNano()%int64(a.every)) < a.duration
+}
+
+// anomalyActive reports whether any scheduled window of kind is running now
+func anomalyActive(kind string) bool {
+	now := time.Now()
+	for _, a := range cfg.AnomalySchedule {
+		if a.kind == kind && a.activeAt(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// anomalyLatency is the INCIDENT_LATENCY a latency anomaly adds to /work, at
+// full strength for the whole window rather than decaying like an incident
+func anomalyLatency() time.Duration {
+	if anomalyActive("latency") {
+		return cfg.IncidentLatency
+	}
+	return 0
+}
+
+// anomalyGauges report each kind's state, so an alert that fired can be
+// lined up against the window that caused it
+func anomalyGauges() []prometheus.Collector {
+	var gauges []prometheus.Collector
+	for _, kind := range anomalyKinds {
+		gauges = append(gauges, prometheus.NewGaugeFunc(
+			prometheus.GaugeOpts{
+				Name:        "anomaly_active",
+				Help:        "1 while a scheduled ANOMALY_SCHEDULE window of this kind is running",
+				ConstLabels: prometheus.Labels{"kind": kind},
+			},
+			func() float64 {
+				if anomalyActive(kind) {
+					return 1
+				}
+				return 0
+			},
+		))
+	}
+	return gauges
+}
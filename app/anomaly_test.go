@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAnomalyWindowAlignment(t *testing.T) {
+	w := anomalyWindow{every: 15 * time.Minute, duration: time.Minute, kind: "errors"}
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		at   time.Duration
+		want bool
+	}{
+		{0, true},
+		{59 * time.Second, true},
+		{time.Minute, false},
+		{14 * time.Minute, false},
+		{15 * time.Minute, true},
+		{45*time.Minute + 30*time.Second, true},
+	} {
+		if got := w.activeAt(base.Add(tc.at)); got != tc.want {
+			t.Errorf("activeAt(10:00 + %v) = %v, want %v", tc.at, got, tc.want)
+		}
+	}
+}
+
+// sleepUntilPhase waits for the point in every 4s period, aligned to the
+// epoch like an anomaly schedule, that lies between from and to
+func sleepUntilPhase(from, to time.Duration) {
+	const period = 4 * time.Second
+	for {
+		phase := time.Duration(time.Now().UnixNano() % int64(period))
+		if phase >= from && phase < to {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestAnomalyScheduleErrors(t *testing.T) {
+	app := startApp(t, "ANOMALY_SCHEDULE=4s/2s:errors", "INCIDENT_FAILURE_RATE=1")
+
+	sleepUntilPhase(2200*time.Millisecond, 3*time.Second)
+	if v := app.metricValue("anomaly_active", "kind", "errors"); v != 0 {
+		t.Errorf("anomaly_active outside the window = %v, want 0", v)
+	}
+	for _, code := range app.getConcurrently(5, "/work") {
+		if code != http.StatusOK {
+			t.Errorf("/work gave %d outside the window, want 200", code)
+		}
+	}
+
+	sleepUntilPhase(200*time.Millisecond, time.Second)
+	if v := app.metricValue("anomaly_active", "kind", "errors"); v != 1 {
+		t.Errorf("anomaly_active inside the window = %v, want 1", v)
+	}
+	for _, code := range app.getConcurrently(5, "/work") {
+		if code != http.StatusInternalServerError {
+			t.Errorf("/work gave %d inside the window, want 500", code)
+		}
+	}
+}
+
+func TestAnomalyScheduleLatency(t *testing.T) {
+	app := startApp(t, "ANOMALY_SCHEDULE=4s/2s:latency", "INCIDENT_LATENCY=800ms")
+
+	sleepUntilPhase(200*time.Millisecond, 700*time.Millisecond)
+	if v := app.metricValue("anomaly_active", "kind", "latency"); v != 1 {
+		t.Errorf("anomaly_active inside the window = %v, want 1", v)
+	}
+	app.get("/work")
+	span := app.spansNamed("work", 1)[0]
+	if d := span.EndTime.Sub(span.StartTime); d < 800*time.Millisecond {
+		t.Errorf("/work took %v inside a latency window, want at least 800ms", d)
+	}
+}
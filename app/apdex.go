@@ -0,0 +1,54 @@
+package main
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var apdexScore = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "apdex_score",
+		Help: "Apdex of /work over the last APDEX_WINDOW: (satisfied + tolerating/2) / total, NaN if there were no requests",
+	},
+)
+
+// Counts for the current window; reset each time the score is published
+var apdex struct {
+	sync.Mutex
+	satisfied, tolerating, total int
+}
+
+// recordApdex classifies one request against the target T: satisfied up to
+// T, tolerating up to 4T, frustrated beyond that. Failed requests count as
+// frustrated no matter how fast they were.
+func recordApdex(d time.Duration, failed bool) {
+	apdex.Lock()
+	defer apdex.Unlock()
+	apdex.total++
+	switch {
+	case failed:
+	case d <= cfg.ApdexTarget:
+		apdex.satisfied++
+	case d <= 4*cfg.ApdexTarget:
+		apdex.tolerating++
+	}
+}
+
+// publishApdex sets apdex_score from each window's requests, so the gauge
+// follows recent traffic instead of averaging over the process lifetime
+func publishApdex(window time.Duration) {
+	apdexScore.Set(math.NaN())
+	backgroundPool.every(window, func() {
+		apdex.Lock()
+		score := math.NaN()
+		if apdex.total > 0 {
+			score = (float64(apdex.satisfied) + float64(apdex.tolerating)/2) / float64(apdex.total)
+		}
+		apdex.satisfied, apdex.tolerating, apdex.total = 0, 0, 0
+		apdex.Unlock()
+		apdexScore.Set(score)
+	})
+}
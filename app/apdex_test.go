@@ -0,0 +1,59 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestApdexScore(t *testing.T) {
+	saved := cfg.ApdexTarget
+	defer func() { cfg.ApdexTarget = saved }()
+	cfg.ApdexTarget = 100 * time.Millisecond
+
+	recordApdex(50*time.Millisecond, false)  // satisfied
+	recordApdex(100*time.Millisecond, false) // satisfied, T itself
+	recordApdex(300*time.Millisecond, false) // tolerating
+	recordApdex(500*time.Millisecond, false) // frustrated
+	recordApdex(10*time.Millisecond, true)   // frustrated, it failed
+
+	apdexScore.Set(math.NaN())
+	backgroundPool = newWorkerPool(1)
+	go publishApdex(50 * time.Millisecond)
+	deadline := time.Now().Add(5 * time.Second)
+	for math.IsNaN(testutil.ToFloat64(apdexScore)) {
+		if time.Now().After(deadline) {
+			t.Fatal("apdex_score was never published")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	// (2 satisfied + 1 tolerating / 2) / 5
+	if v := testutil.ToFloat64(apdexScore); v != 0.5 {
+		t.Errorf("apdex_score = %v, want 0.5", v)
+	}
+}
+
+func TestApdexScoreScraped(t *testing.T) {
+	for _, tc := range []struct {
+		target string
+		want   float64
+	}{
+		// ap-south takes at least 150ms, far past 4 * 1ms
+		{"1ms", 0},
+		{"1h", 1},
+	} {
+		t.Run(tc.target, func(t *testing.T) {
+			app := startApp(t, "APDEX_T="+tc.target, "APDEX_WINDOW=500ms", "REGION=ap-south")
+			app.getConcurrently(5, "/work")
+			app.waitFor("apdex_score", func() bool {
+				v, ok := seriesValue(app.scrape(), "apdex_score")
+				return ok && !math.IsNaN(v)
+			})
+			if v := app.metricValue("apdex_score"); v != tc.want {
+				t.Errorf("apdex_score = %v, want %v", v, tc.want)
+			}
+		})
+	}
+}
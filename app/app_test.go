@@ -0,0 +1,502 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// Built once in TestMain; most tests run the real binary, since the app
+// wires its telemetry up in main
+var appBinary string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "sample-app-test")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	appBinary = filepath.Join(dir, "sample-app")
+	if out, err := exec.Command("go", "build", "-o", appBinary, ".").CombinedOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to build sample-app: %v\n%s", err, out)
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// testApp is one running sample-app process, exporting to its own fake
+// collector and writing every span to stdout as JSON
+type testApp struct {
+	t         *testing.T
+	cmd       *exec.Cmd
+	url       string
+	addr      string
+	collector *fakeCollector
+
+	metricsPath string
+	stdout      io.ReadCloser
+
+	mu     sync.Mutex
+	lines  []string
+	exited chan struct{}
+	err    error
+}
+
+var listenLine = regexp.MustCompile(`Starting server on \w+/(\S+)`)
+
+// startApp runs the app with env on top of the test defaults and waits until
+// it is listening. It is stopped when the test ends.
+func startApp(t *testing.T, env ...string) *testApp {
+	t.Helper()
+	a := launchApp(t, env...)
+	a.waitFor("the server to listen", func() bool {
+		for _, line := range a.output() {
+			if m := listenLine.FindStringSubmatch(line); m != nil {
+				a.addr = m[1]
+				a.url = "http://" + a.addr
+				return true
+			}
+		}
+		return false
+	})
+	return a
+}
+
+// launchApp is startApp without waiting, for apps that may never listen
+func launchApp(t *testing.T, env ...string) *testApp {
+	t.Helper()
+	collector := startCollector(t)
+	a := &testApp{t: t, collector: collector, exited: make(chan struct{})}
+	a.cmd = exec.Command(appBinary)
+	a.cmd.Env = append(os.Environ(),
+		"LISTEN_ADDR=127.0.0.1:0",
+		"SPAN_JSON_EXPORT=stdout",
+		"OTLP_ENDPOINTS="+collector.addr,
+		"FAILURE_RATE=0",
+		"SHUTDOWN_FLUSH_TIMEOUT=2s",
+		"SHUTDOWN_TIMEOUT=2s",
+	)
+	a.cmd.Env = append(a.cmd.Env, env...)
+	a.metricsPath = "/metrics"
+	for _, kv := range env {
+		if v, ok := strings.CutPrefix(kv, "METRICS_PATH="); ok {
+			a.metricsPath = v
+		}
+	}
+	stdout, err := a.cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.stdout = stdout
+	stderr, err := a.cmd.StderrPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	var readers sync.WaitGroup
+	for _, r := range []io.Reader{stdout, stderr} {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			s := bufio.NewScanner(r)
+			s.Buffer(make([]byte, 0, 64<<10), 4<<20)
+			for s.Scan() {
+				a.mu.Lock()
+				a.lines = append(a.lines, s.Text())
+				a.mu.Unlock()
+			}
+		}()
+	}
+	go func() {
+		readers.Wait()
+		a.err = a.cmd.Wait()
+		close(a.exited)
+	}()
+	t.Cleanup(func() { a.stop() })
+	return a
+}
+
+// wait waits for the app to exit on its own and returns its exit code
+func (a *testApp) wait() int {
+	a.t.Helper()
+	select {
+	case <-a.exited:
+	case <-time.After(10 * time.Second):
+		a.t.Fatalf("app still running after 10s; output:\n%s", strings.Join(a.output(), "\n"))
+	}
+	return a.cmd.ProcessState.ExitCode()
+}
+
+// stop sends SIGTERM and waits for the app to exit, returning its exit code
+func (a *testApp) stop() int {
+	select {
+	case <-a.exited:
+	default:
+		a.cmd.Process.Signal(syscall.SIGTERM)
+		select {
+		case <-a.exited:
+		case <-time.After(15 * time.Second):
+			a.cmd.Process.Kill()
+			<-a.exited
+		}
+	}
+	return a.cmd.ProcessState.ExitCode()
+}
+
+func (a *testApp) signal(sig os.Signal) {
+	if err := a.cmd.Process.Signal(sig); err != nil {
+		a.t.Fatal(err)
+	}
+}
+
+// waitFor polls cond until it holds, failing the test after 10s
+func (a *testApp) waitFor(what string, cond func() bool) {
+	a.t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		select {
+		case <-a.exited:
+			if cond() {
+				return
+			}
+			a.t.Fatalf("app exited (%v) while waiting for %s; output:\n%s", a.err, what, strings.Join(a.output(), "\n"))
+		default:
+		}
+		if time.Now().After(deadline) {
+			a.t.Fatalf("timed out waiting for %s; output:\n%s", what, strings.Join(a.output(), "\n"))
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func (a *testApp) output() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]string(nil), a.lines...)
+}
+
+// get requests path with optional header name/value pairs
+func (a *testApp) get(path string, header ...string) (*http.Response, string) {
+	a.t.Helper()
+	return a.do(http.MethodGet, path, nil, header...)
+}
+
+func (a *testApp) do(method, path string, body io.Reader, header ...string) (*http.Response, string) {
+	a.t.Helper()
+	req, err := http.NewRequest(method, a.url+path, body)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	return resp, string(b)
+}
+
+// exportedSpan is the subset of the stdout exporter's JSON the tests use
+type exportedSpan struct {
+	Name        string
+	SpanContext struct {
+		TraceID string
+		SpanID  string
+	}
+	Parent struct {
+		TraceID string
+		SpanID  string
+	}
+	SpanKind   int
+	StartTime  time.Time
+	EndTime    time.Time
+	Attributes []exportedAttr
+	Events     []struct {
+		Name       string
+		Attributes []exportedAttr
+		Time       time.Time
+	}
+	Links []struct {
+		SpanContext struct {
+			TraceID string
+			SpanID  string
+		}
+	}
+	Status struct {
+		Code        string
+		Description string
+	}
+	Resource             []exportedAttr
+	InstrumentationScope struct {
+		Name string
+	}
+}
+
+type exportedAttr struct {
+	Key   string
+	Value struct {
+		Type  string
+		Value any
+	}
+}
+
+// attr returns the value of key, or nil if it isn't set
+func (s exportedSpan) attr(key string) any {
+	return attrValue(s.Attributes, key)
+}
+
+func attrValue(attrs []exportedAttr, key string) any {
+	for _, kv := range attrs {
+		if kv.Key == key {
+			return kv.Value.Value
+		}
+	}
+	return nil
+}
+
+func (a *testApp) spans() []exportedSpan {
+	var spans []exportedSpan
+	for _, line := range a.output() {
+		if !strings.HasPrefix(line, `{"Name":`) {
+			continue
+		}
+		var s exportedSpan
+		if err := json.Unmarshal([]byte(line), &s); err != nil {
+			a.t.Fatalf("bad span line %q: %v", line, err)
+		}
+		spans = append(spans, s)
+	}
+	return spans
+}
+
+// spansNamed waits until at least n spans called name have been exported
+func (a *testApp) spansNamed(name string, n int) []exportedSpan {
+	a.t.Helper()
+	var found []exportedSpan
+	a.waitFor(fmt.Sprintf("%d %q spans", n, name), func() bool {
+		found = found[:0]
+		for _, s := range a.spans() {
+			if s.Name == name {
+				found = append(found, s)
+			}
+		}
+		return len(found) >= n
+	})
+	return found
+}
+
+// children returns the exported spans whose parent is s
+func (a *testApp) children(s exportedSpan) []exportedSpan {
+	var out []exportedSpan
+	for _, c := range a.spans() {
+		if c.Parent.SpanID == s.SpanContext.SpanID && c.SpanContext.TraceID == s.SpanContext.TraceID {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// logs returns the structured log records, in order
+func (a *testApp) logs() []map[string]any {
+	var records []map[string]any
+	for _, line := range a.output() {
+		if !strings.HasPrefix(line, "{") || strings.HasPrefix(line, `{"Name":`) {
+			continue
+		}
+		var rec map[string]any
+		if json.Unmarshal([]byte(line), &rec) == nil && rec["msg"] != nil {
+			records = append(records, rec)
+		}
+	}
+	return records
+}
+
+// logsWithMsg waits until a log record with msg appears and returns all of them
+func (a *testApp) logsWithMsg(msg string) []map[string]any {
+	a.t.Helper()
+	var found []map[string]any
+	a.waitFor(fmt.Sprintf("a %q log record", msg), func() bool {
+		found = found[:0]
+		for _, rec := range a.logs() {
+			if rec["msg"] == msg {
+				found = append(found, rec)
+			}
+		}
+		return len(found) > 0
+	})
+	return found
+}
+
+// scrape fetches the Prometheus text exposition and parses it
+func (a *testApp) scrape() map[string]*dto.MetricFamily {
+	a.t.Helper()
+	return a.scrapePath(a.metricsPath)
+}
+
+func (a *testApp) scrapePath(path string) map[string]*dto.MetricFamily {
+	a.t.Helper()
+	resp, body := a.get(path, "Accept", "text/plain")
+	if resp.StatusCode != http.StatusOK {
+		a.t.Fatalf("scrape %s: %s\n%s", path, resp.Status, body)
+	}
+	families, err := new(expfmt.TextParser).TextToMetricFamilies(strings.NewReader(body))
+	if err != nil {
+		a.t.Fatalf("parse scrape: %v", err)
+	}
+	return families
+}
+
+// seriesValue returns the value of the series of name whose labels include
+// labels: a counter or gauge value, or a histogram/summary sample count.
+// ok is false if there is no such series.
+func seriesValue(families map[string]*dto.MetricFamily, name string, labels ...string) (v float64, ok bool) {
+	mf := families[name]
+	if mf == nil {
+		return 0, false
+	}
+	for _, m := range mf.GetMetric() {
+		if !hasLabels(m, labels) {
+			continue
+		}
+		switch {
+		case m.Counter != nil:
+			return m.Counter.GetValue(), true
+		case m.Gauge != nil:
+			return m.Gauge.GetValue(), true
+		case m.Untyped != nil:
+			return m.Untyped.GetValue(), true
+		case m.Histogram != nil:
+			return float64(m.Histogram.GetSampleCount()), true
+		case m.Summary != nil:
+			return float64(m.Summary.GetSampleCount()), true
+		}
+	}
+	return 0, false
+}
+
+func hasLabels(m *dto.Metric, labels []string) bool {
+	for i := 0; i+1 < len(labels); i += 2 {
+		found := false
+		for _, lp := range m.GetLabel() {
+			if lp.GetName() == labels[i] && lp.GetValue() == labels[i+1] {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// metricValue is seriesValue for a series the test expects to exist
+func (a *testApp) metricValue(name string, labels ...string) float64 {
+	a.t.Helper()
+	v, ok := seriesValue(a.scrape(), name, labels...)
+	if !ok {
+		a.t.Fatalf("no %s series with labels %v", name, labels)
+	}
+	return v
+}
+
+// lineContaining waits until an output line contains s and returns it
+func (a *testApp) lineContaining(s string) string {
+	a.t.Helper()
+	var found string
+	a.waitFor(fmt.Sprintf("output containing %q", s), func() bool {
+		for _, line := range a.output() {
+			if strings.Contains(line, s) {
+				found = line
+				return true
+			}
+		}
+		return false
+	})
+	return found
+}
+
+// stubServer answers with statuses in turn, repeating the last one, and
+// keeps the headers of every request it gets
+type stubServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	headers  []http.Header
+}
+
+func startStub(t *testing.T, statuses ...int) *stubServer {
+	t.Helper()
+	s := &stubServer{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		status := s.statuses[min(len(s.headers), len(s.statuses)-1)]
+		s.headers = append(s.headers, r.Header.Clone())
+		s.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *stubServer) requests() []http.Header {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]http.Header(nil), s.headers...)
+}
+
+// getConcurrently sends n requests for path at once and returns their
+// status codes, 0 for a request that failed outright
+func (a *testApp) getConcurrently(n int, path string, header ...string) []int {
+	statuses := make([]int, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, err := http.NewRequest(http.MethodGet, a.url+path, nil)
+			if err != nil {
+				return
+			}
+			for j := 0; j+1 < len(header); j += 2 {
+				req.Header.Set(header[j], header[j+1])
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			statuses[i] = resp.StatusCode
+		}()
+	}
+	wg.Wait()
+	return statuses
+}
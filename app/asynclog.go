@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var logRecordsDropped = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "log_records_dropped_total",
+		Help: "Log records dropped because the LOG_ASYNC_BUFFER was full, or written after the writer was closed",
+	},
+)
+
+// asyncLogWriter takes log records off the request path: Write only queues
+// the record, and one goroutine batches them into out, flushing every
+// interval. When the queue is full the record is dropped and counted rather
+// than blocking the caller.
+type asyncLogWriter struct {
+	records chan []byte
+	stop    chan struct{}
+	done    chan struct{}
+
+	// Held for reading while queueing, so close knows no Write is halfway
+	// through a send once it has the write lock
+	mu     sync.RWMutex
+	closed bool
+}
+
+func newAsyncLogWriter(out io.Writer, size int, interval time.Duration) *asyncLogWriter {
+	w := &asyncLogWriter{
+		records: make(chan []byte, size),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go w.run(bufio.NewWriter(out), interval)
+	return w
+}
+
+func (w *asyncLogWriter) Write(p []byte) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	// Background goroutines can still log during shutdown; there's nobody
+	// left to write their records out
+	if w.closed {
+		logRecordsDropped.Inc()
+		return len(p), nil
+	}
+	// slog reuses its buffer once Write returns
+	select {
+	case w.records <- append([]byte(nil), p...):
+	default:
+		logRecordsDropped.Inc()
+	}
+	return len(p), nil
+}
+
+func (w *asyncLogWriter) run(out *bufio.Writer, interval time.Duration) {
+	defer close(w.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case p := <-w.records:
+			out.Write(p)
+		case <-w.stop:
+			for len(w.records) > 0 {
+				out.Write(<-w.records)
+			}
+			out.Flush()
+			return
+		case <-ticker.C:
+			out.Flush()
+		}
+	}
+}
+
+// close writes out everything still queued. Records written after it are
+// dropped and counted.
+func (w *asyncLogWriter) close() {
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+	close(w.stop)
+	<-w.done
+}
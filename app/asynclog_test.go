@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// gatedWriter blocks every Write until open is closed
+type gatedWriter struct {
+	open chan struct{}
+	mu   sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (g *gatedWriter) Write(p []byte) (int, error) {
+	<-g.open
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.buf.Write(p)
+}
+
+func (g *gatedWriter) String() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.buf.String()
+}
+
+func TestAsyncLogWriterFlushes(t *testing.T) {
+	out := &gatedWriter{open: make(chan struct{})}
+	close(out.open)
+	w := newAsyncLogWriter(out, 16, 20*time.Millisecond)
+	w.Write([]byte("first\n"))
+	w.Write([]byte("second\n"))
+
+	// The periodic flush gets them out without waiting for close
+	deadline := time.Now().Add(5 * time.Second)
+	for out.String() != "first\nsecond\n" {
+		if time.Now().After(deadline) {
+			t.Fatalf("output = %q after the flush interval", out.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	w.close()
+}
+
+func TestAsyncLogWriterDropsOnOverflow(t *testing.T) {
+	out := &gatedWriter{open: make(chan struct{})}
+	w := newAsyncLogWriter(out, 2, time.Hour)
+	before := testutil.ToFloat64(logRecordsDropped)
+
+	// Records bigger than bufio's buffer go straight to the blocked writer:
+	// one is stuck there, two fill the queue, the rest are dropped
+	record := func(i int) []byte { return []byte(strings.Repeat(string('a'+rune(i)), 8192) + "\n") }
+	w.Write(record(0))
+	deadline := time.Now().Add(5 * time.Second)
+	for len(w.records) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the first record was never picked up")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	start := time.Now()
+	for i := 1; i < 6; i++ {
+		w.Write(record(i))
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("writing to a full queue took %v, want it not to block", d)
+	}
+	if v := testutil.ToFloat64(logRecordsDropped) - before; v != 3 {
+		t.Errorf("dropped %v records, want 3", v)
+	}
+
+	close(out.open)
+	w.close()
+	if got, want := out.String(), string(record(0))+string(record(1))+string(record(2)); got != want {
+		t.Errorf("wrote %d bytes, want the first three records (%d bytes)", len(got), len(want))
+	}
+
+	// Nothing is left to write records after close
+	w.Write([]byte("late\n"))
+	if v := testutil.ToFloat64(logRecordsDropped) - before; v != 4 {
+		t.Errorf("dropped %v records after close, want 4", v)
+	}
+}
+
+func TestAsyncLogsAppear(t *testing.T) {
+	app := startApp(t, "LOG_ASYNC_BUFFER=1024", "LOG_FLUSH_INTERVAL=50ms", "ACCESS_LOG=true")
+	app.get("/work")
+	app.logsWithMsg("access")
+}
+
+func TestAsyncLogsFlushedOnExit(t *testing.T) {
+	// Nothing is flushed on the interval, so the record only gets out on close
+	app := startApp(t, "LOG_ASYNC_BUFFER=1024", "LOG_FLUSH_INTERVAL=1h", "ACCESS_LOG=true")
+	app.get("/work")
+	if code := app.stop(); code != 0 {
+		t.Errorf("exit code %d", code)
+	}
+	found, flushed := false, false
+	for _, l := range app.logs() {
+		found = found || l["msg"] == "access"
+		flushed = flushed || l["msg"] == "telemetry flushed"
+	}
+	if !found {
+		t.Error("the buffered access record was lost on exit")
+	}
+	// Records queued during shutdown are written out before exit
+	if !flushed {
+		t.Error("shutdown logs were lost")
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var adminActions = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "admin_actions_total",
+		Help: "Requests to /admin/* endpoints, by action and whether they succeeded",
+	},
+	[]string{"action", "result"},
+)
+
+// withAudit leaves an audit record of every call to an admin endpoint: what
+// was done, from where, and how it went. It sits outside withRecovery so a
+// panicking handler is audited with its 500.
+func withAudit(next http.Handler, route string) http.Handler {
+	if !cfg.AdminAuditLog || !strings.HasPrefix(route, "admin_") {
+		return next
+	}
+	action := strings.TrimPrefix(route, "admin_")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		result := "success"
+		if rec.status >= 400 {
+			result = "failure"
+		}
+		adminActions.WithLabelValues(action, result).Inc()
+
+		source := r.RemoteAddr
+		if addr, ok := clientAddr(r); ok {
+			source = addr.String()
+		}
+		sc := trace.SpanContextFromContext(r.Context())
+		logger.Info("audit",
+			"action", action,
+			"method", r.Method,
+			"path", r.URL.Path,
+			"query", r.URL.RawQuery,
+			"source_ip", source,
+			"result", result,
+			"status", rec.status,
+			"trace_id", sc.TraceID().String(),
+			"span_id", sc.SpanID().String(),
+		)
+	})
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAdminAuditRecords(t *testing.T) {
+	app := startApp(t)
+	app.get("/admin/sampling-stats?verbose=1")
+	app.get("/admin/panic")
+	app.get("/healthz")
+
+	records := map[string]map[string]any{}
+	app.waitFor("two audit records", func() bool {
+		for _, l := range app.logs() {
+			if l["msg"] == "audit" {
+				records[l["action"].(string)] = l
+			}
+		}
+		return len(records) >= 2
+	})
+	stats := records["sampling_stats"]
+	if stats == nil {
+		t.Fatalf("no audit record for sampling_stats in %v", records)
+	}
+	for key, want := range map[string]any{
+		"method": "GET",
+		"path":   "/admin/sampling-stats",
+		"query":  "verbose=1",
+		"result": "success",
+		"status": float64(200),
+	} {
+		if stats[key] != want {
+			t.Errorf("audit %s = %v, want %v", key, stats[key], want)
+		}
+	}
+	if ip, _ := stats["source_ip"].(string); !strings.HasPrefix(ip, "127.0.0.1") {
+		t.Errorf("audit source_ip = %q, want the loopback client", ip)
+	}
+	if stats["trace_id"] != app.spansNamed("admin_sampling_stats", 1)[0].SpanContext.TraceID {
+		t.Errorf("audit trace_id = %v, want the request's trace", stats["trace_id"])
+	}
+	if p := records["panic"]; p == nil || p["result"] != "failure" || p["status"] != float64(500) {
+		t.Errorf("panic audit record = %v, want a failure with status 500", p)
+	}
+	if len(records) != 2 {
+		t.Errorf("audited actions %v, want only the two admin calls", records)
+	}
+
+	families := app.scrape()
+	for _, want := range [][]string{{"sampling_stats", "success"}, {"panic", "failure"}} {
+		if v, _ := seriesValue(families, "admin_actions_total", "action", want[0], "result", want[1]); v != 1 {
+			t.Errorf("admin_actions_total{action=%q,result=%q} = %v, want 1", want[0], want[1], v)
+		}
+	}
+}
+
+func TestAdminAuditDisabled(t *testing.T) {
+	app := startApp(t, "ADMIN_AUDIT_LOG=false")
+	app.get("/admin/sampling-stats")
+	app.spansNamed("admin_sampling_stats", 1)
+	for _, l := range app.logs() {
+		if l["msg"] == "audit" {
+			t.Errorf("audit record %v with ADMIN_AUDIT_LOG=false", l)
+		}
+	}
+	if _, ok := seriesValue(app.scrape(), "admin_actions_total"); ok {
+		t.Error("admin action counted with ADMIN_AUDIT_LOG=false")
+	}
+}
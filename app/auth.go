@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var authFailures = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "auth_failures_total",
+		Help: "Requests rejected with 401 by the simulated authentication phase",
+	},
+)
+
+var authLatency = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Name:    "auth_latency_seconds",
+		Help:    "Time spent in the simulated authentication phase",
+		Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5},
+	},
+)
+
+var errAuthFailed = errors.New("invalid credentials")
+
+// withAuth runs a simulated authentication phase in front of the app's own
+// routes: AUTH_LATENCY in an "authenticate" span, then a 401 for
+// AUTH_FAILURE_RATE of the requests. Probes, admin endpoints and the
+// /admin/consistency self-test skip it.
+func withAuth(next http.Handler, route string) http.Handler {
+	if !cfg.AuthEnabled || route == "healthz" || route == "readyz" || strings.HasPrefix(route, "admin_") {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isConsistencyProbe(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := r.Context()
+		start := time.Now()
+		// Not a startPhase: a rejected request must show why at every
+		// SPAN_DETAIL, or its trace is just a 401 with nothing under it
+		_, span := otel.Tracer("app").Start(ctx, "authenticate", trace.WithSpanKind(trace.SpanKindInternal))
+		err := sleepCtx(ctx, cfg.AuthLatency)
+		if err == nil && rand.Float64() < cfg.AuthFailureRate {
+			err = errAuthFailed
+		}
+		authLatency.Observe(time.Since(start).Seconds())
+		span.SetAttributes(attribute.Bool("auth.success", err == nil))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+		switch {
+		case errors.Is(err, errAuthFailed):
+			authFailures.Inc()
+			trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("auth.success", false))
+			requestLogger(ctx).Warn("authentication failed", "path", r.URL.Path)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="sample-app"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		case err != nil:
+			abortRequest(ctx, w, route, requestLogger(ctx))
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
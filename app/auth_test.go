@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAuthFailure(t *testing.T) {
+	app := startApp(t, "AUTH_ENABLED=true", "AUTH_FAILURE_RATE=1", "AUTH_LATENCY=30ms")
+
+	resp, _ := app.get("/work")
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", resp.StatusCode)
+	}
+	if h := resp.Header.Get("WWW-Authenticate"); h == "" {
+		t.Error("401 without WWW-Authenticate")
+	}
+
+	auth := app.spansNamed("authenticate", 1)[0]
+	if auth.Status.Code != "Error" || auth.Status.Description != "invalid credentials" {
+		t.Errorf("authenticate status = %+v, want Error: invalid credentials", auth.Status)
+	}
+	if v := auth.attr("auth.success"); v != false {
+		t.Errorf("auth.success = %v, want false", v)
+	}
+	if d := auth.EndTime.Sub(auth.StartTime); d < 30*time.Millisecond {
+		t.Errorf("authenticate took %v, want AUTH_LATENCY's 30ms", d)
+	}
+	server := app.spansNamed("work", 1)[0]
+	if auth.Parent.SpanID != server.SpanContext.SpanID {
+		t.Error("authenticate isn't a child of the request span")
+	}
+	if kids := app.children(server); len(kids) != 1 {
+		t.Errorf("rejected request has %d child spans, want only authenticate", len(kids))
+	}
+
+	if v := app.metricValue("auth_failures_total"); v != 1 {
+		t.Errorf("auth_failures_total = %v, want 1", v)
+	}
+	if v := app.metricValue("auth_latency_seconds"); v != 1 {
+		t.Errorf("auth_latency_seconds has %v samples, want 1", v)
+	}
+	app.logsWithMsg("authentication failed")
+}
+
+func TestAuthSkipsProbes(t *testing.T) {
+	app := startApp(t, "AUTH_ENABLED=true", "AUTH_FAILURE_RATE=1")
+	if resp, _ := app.get("/healthz"); resp.StatusCode != http.StatusOK {
+		t.Errorf("/healthz = %d, want probes to skip auth", resp.StatusCode)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var batchProgress = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "batch_progress",
+		Help: "Share of the most recent /batch job's items processed so far, from 0 to 1",
+	},
+)
+
+// Upper bound on the items a single /batch job may process
+const maxBatchItems = 2000
+
+// Items processed per chunk span, and the time each item takes
+const (
+	batchChunkSize = 25
+	batchItemTime  = time.Millisecond
+)
+
+// batchHandler runs a small ETL-style job: the items are processed in
+// chunks, one chunk span each, all under the request's server span, and
+// batch_progress climbs from 0 to 1 as chunks finish
+func batchHandler(w http.ResponseWriter, r *http.Request) {
+	items := 100
+	if raw := r.URL.Query().Get("items"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 1 || v > maxBatchItems {
+			http.Error(w, fmt.Sprintf("items must be between 1 and %d", maxBatchItems), http.StatusBadRequest)
+			return
+		}
+		items = v
+	}
+
+	ctx := r.Context()
+	log := requestLogger(ctx)
+
+	batchProgress.Set(0)
+	chunks := 0
+	for done := 0; done < items; chunks++ {
+		n := min(batchChunkSize, items-done)
+		_, chunkSpan := otel.Tracer("app").Start(ctx, "chunk",
+			trace.WithSpanKind(trace.SpanKindInternal),
+			trace.WithAttributes(
+				attribute.Int("batch.chunk.index", chunks),
+				attribute.Int("batch.chunk.items", n),
+			),
+		)
+		err := sleepCtx(ctx, time.Duration(n)*batchItemTime)
+		chunkSpan.End()
+		if err != nil {
+			abortRequest(ctx, w, "batch", log)
+			return
+		}
+		done += n
+		batchProgress.Set(float64(done) / float64(items))
+	}
+
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.Int("batch.items", items),
+		attribute.Int("batch.chunks", chunks),
+	)
+	log.Info("batch completed", "items", items, "chunks", chunks)
+	fmt.Fprintf(w, "Processed %d items in %d chunks\n", items, chunks)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestBatchChunksAndProgress(t *testing.T) {
+	app := startApp(t)
+	if resp, body := app.get("/batch?items=60"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", resp.StatusCode, body)
+	}
+	if v := app.metricValue("batch_progress"); v != 1 {
+		t.Errorf("batch_progress = %v after the job, want 1", v)
+	}
+
+	server := app.spansNamed("batch", 1)[0]
+	app.spansNamed("chunk", 3)
+	chunks := app.children(server)
+	if len(chunks) != 3 {
+		t.Fatalf("job has %d chunk spans, want 3", len(chunks))
+	}
+	items := map[float64]float64{}
+	for _, c := range chunks {
+		items[c.attr("batch.chunk.index").(float64)] = c.attr("batch.chunk.items").(float64)
+	}
+	if items[0] != 25 || items[1] != 25 || items[2] != 10 {
+		t.Errorf("chunk sizes by index = %v, want 25, 25 and 10", items)
+	}
+	if server.attr("batch.items") != float64(60) || server.attr("batch.chunks") != float64(3) {
+		t.Errorf("job span has batch.items %v and batch.chunks %v, want 60 and 3", server.attr("batch.items"), server.attr("batch.chunks"))
+	}
+}
+
+func TestBatchProgressMidJob(t *testing.T) {
+	app := startApp(t)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		http.Get(app.url + "/batch?items=1000")
+	}()
+
+	// The job takes about a second, so scrapes land part of the way through
+	var partial bool
+	for !partial {
+		select {
+		case <-done:
+			t.Fatal("job finished without a scrape seeing it part done")
+		default:
+		}
+		v, _ := seriesValue(app.scrape(), "batch_progress")
+		partial = v > 0 && v < 1
+	}
+	<-done
+	if v := app.metricValue("batch_progress"); v != 1 {
+		t.Errorf("batch_progress = %v after the job, want 1", v)
+	}
+}
+
+func TestBatchItemBounds(t *testing.T) {
+	app := startApp(t)
+	for _, items := range []string{"0", "2001"} {
+		if resp, _ := app.get("/batch?items=" + items); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("items=%s: status = %d, want 400", items, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"runtime"
+	"runtime/debug"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const serviceVersion = "1.0.0"
+
+// Git commit the binary was built from, injected at build time with
+// -ldflags "-X main.commit=<sha>". Left empty, it falls back to the
+// revision the Go toolchain stamps into binaries built inside a checkout.
+var commit string
+
+// buildCommit returns the commit SHA, or "unknown" when there is none
+func buildCommit() string {
+	if commit != "" {
+		return commit
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" && s.Value != "" {
+				return s.Value
+			}
+		}
+	}
+	return "unknown"
+}
+
+// newBuildInfo is the usual constant-1 build_info series, so any metric can
+// be joined with the version and commit it came from
+func newBuildInfo() prometheus.Collector {
+	return prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "build_info",
+			Help: "Always 1; labels identify the running build",
+			ConstLabels: prometheus.Labels{
+				"version":   serviceVersion,
+				"commit":    buildCommit(),
+				"goversion": runtime.Version(),
+			},
+		},
+		func() float64 { return 1 },
+	)
+}
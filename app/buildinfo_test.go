@@ -0,0 +1,28 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommitSHAOnResourceAndBuildInfo(t *testing.T) {
+	const sha = "0123456789abcdef0123456789abcdef01234567"
+	bin := filepath.Join(t.TempDir(), "sample-app")
+	build := exec.Command("go", "build", "-ldflags", "-X main.commit="+sha, "-o", bin, ".")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("building with the commit set: %v\n%s", err, out)
+	}
+	saved := appBinary
+	appBinary = bin
+	app := startApp(t)
+	appBinary = saved
+
+	app.get("/healthz")
+	if v := attrValue(app.spansNamed("healthz", 1)[0].Resource, "vcs.revision"); v != sha {
+		t.Errorf("resource vcs.revision = %v, want %s", v, sha)
+	}
+	if v := app.metricValue("build_info", "commit", sha, "version", serviceVersion); v != 1 {
+		t.Errorf("build_info{commit=%q} = %v, want 1", sha, v)
+	}
+}
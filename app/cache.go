@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var cacheHits = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "cache_hits_total",
+		Help: "Simulated cache lookups served from the cache",
+	},
+)
+
+var cacheMisses = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "cache_misses_total",
+		Help: "Simulated cache lookups that fell through to the database",
+	},
+)
+
+var cacheEvictions = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "cache_evictions_total",
+		Help: "Entries evicted from the simulated cache by the background evictor",
+	},
+)
+
+// lookupCache simulates a read-through cache in front of a database: hits
+// return quickly, misses pay for a db_query phase. With CACHE_HIT_RATIO
+// somewhere in between this produces a bimodal latency distribution.
+// DB_FAILURE_RATE of the queries fail with errDBQuery. Each query holds a
+// connection from the simulated pool, if DB_POOL_SIZE is set.
+func lookupCache(ctx context.Context) error {
+	span := trace.SpanFromContext(ctx)
+	span.AddEvent("cache_start")
+	_, cacheSpan := startPhase(ctx, "db_cache_lookup")
+	hit := rand.Float64() < cfg.CacheHitRatio
+	cacheSpan.SetAttributes(attribute.Bool("cache.hit", hit))
+	err := sleepCtx(ctx, time.Duration(rand.Intn(20))*time.Millisecond)
+	cacheSpan.End()
+	if err != nil {
+		return err
+	}
+
+	if hit {
+		cacheHits.Inc()
+		span.AddEvent("cache_end", trace.WithAttributes(attribute.Bool("cache.hit", true)))
+		return nil
+	}
+	cacheMisses.Inc()
+
+	release, err := acquireDBConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	_, dbSpan := startPhase(ctx, "db_query")
+	err = sleepCtx(ctx, time.Duration(100+rand.Intn(200))*time.Millisecond)
+	if err == nil && rand.Float64() < cfg.DBFailureRate {
+		err = errDBQuery
+		recordDBFailure()
+		dbSpan.RecordError(err)
+		dbSpan.SetStatus(codes.Error, err.Error())
+	}
+	dbSpan.End()
+	span.AddEvent("cache_end", trace.WithAttributes(attribute.Bool("cache.hit", false)))
+	return err
+}
+
+// Most entries a single eviction pass removes
+const maxEvictions = 100
+
+// evictCache runs an eviction pass every interval, each a discrete event:
+// a cache_eviction span with an event carrying the evicted count, a log
+// line, and a jump in cache_evictions_total. Dashboards can overlay them
+// as annotations on the continuous hit/miss rates.
+func evictCache(interval time.Duration) {
+	backgroundPool.every(interval, func() {
+		count := 1 + rand.Intn(maxEvictions)
+		ctx, span := otel.Tracer("app").Start(context.Background(), "cache_eviction",
+			trace.WithNewRoot(),
+			trace.WithSpanKind(trace.SpanKindInternal),
+		)
+		span.AddEvent("cache_eviction", trace.WithAttributes(attribute.Int("cache.evicted_count", count)))
+		cacheEvictions.Add(float64(count))
+		logger.InfoContext(ctx, "cache eviction", "evicted_count", count)
+		span.End()
+	})
+}
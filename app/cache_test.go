@@ -0,0 +1,56 @@
+package main
+
+import "testing"
+
+func TestCacheHitRatioZeroAlwaysMisses(t *testing.T) {
+	app := startApp(t, "CACHE_HIT_RATIO=0")
+
+	const n = 5
+	// Every /work request goes through the cache, failed or not
+	for range n {
+		app.get("/work")
+	}
+
+	lookups := app.spansNamed("db_cache_lookup", n)
+	for _, s := range lookups {
+		if v := s.attr("cache.hit"); v != false {
+			t.Errorf("cache.hit = %v, want false", v)
+		}
+	}
+	if queries := app.spansNamed("db_query", n); len(queries) != n {
+		t.Errorf("got %d db_query spans, want one per request", len(queries))
+	}
+	if v := app.metricValue("cache_misses_total"); v != n {
+		t.Errorf("cache_misses_total = %v, want %d", v, n)
+	}
+	if v := app.metricValue("cache_hits_total"); v != 0 {
+		t.Errorf("cache_hits_total = %v, want 0", v)
+	}
+}
+
+func TestCacheEvictionEvents(t *testing.T) {
+	app := startApp(t, "CACHE_EVICTION_INTERVAL=100ms")
+	spans := app.spansNamed("cache_eviction", 3)
+
+	total := 0.0
+	for _, s := range spans {
+		if s.Parent.SpanID != "0000000000000000" {
+			t.Errorf("eviction span has parent %s, want a root", s.Parent.SpanID)
+		}
+		if len(s.Events) != 1 || s.Events[0].Name != "cache_eviction" {
+			t.Fatalf("eviction span events = %+v, want one cache_eviction", s.Events)
+		}
+		count, _ := attrValue(s.Events[0].Attributes, "cache.evicted_count").(float64)
+		if count < 1 || count > maxEvictions {
+			t.Errorf("cache.evicted_count = %v, want between 1 and %d", count, maxEvictions)
+		}
+		total += count
+	}
+	if n := len(app.logsWithMsg("cache eviction")); n < len(spans) {
+		t.Errorf("got %d eviction log lines for %d spans", n, len(spans))
+	}
+	// Later passes may have run since
+	if v := app.metricValue("cache_evictions_total"); v < total {
+		t.Errorf("cache_evictions_total = %v, want at least the %v evicted in the spans", v, total)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+var errDBQuery = errors.New("simulated db query failure")
+
+// When the last db_query failed, in Unix nanoseconds (0 = never)
+var lastDBFailure atomic.Int64
+
+// A failed db query makes the next requests more likely to fail too: the
+// extra failure probability starts at CASCADE_FAILURE_BOOST and fades out
+// linearly over CASCADE_WINDOW. Each failure restarts the window, so a bad
+// patch produces a correlated burst of errors rather than independent ones.
+func recordDBFailure() {
+	lastDBFailure.Store(time.Now().UnixNano())
+}
+
+func cascadeBoost() float64 {
+	last := lastDBFailure.Load()
+	if cfg.CascadeWindow <= 0 || last == 0 {
+		return 0
+	}
+	elapsed := time.Since(time.Unix(0, last))
+	if elapsed >= cfg.CascadeWindow {
+		return 0
+	}
+	return cfg.CascadeFailureBoost * (1 - elapsed.Seconds()/cfg.CascadeWindow.Seconds())
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDBFailureBoostsFailureRate(t *testing.T) {
+	app := startApp(t,
+		"FAILURE_RATE=0.1",
+		"CACHE_HIT_RATIO=0",
+		"DB_FAILURE_RATE=1",
+		"CASCADE_WINDOW=1s",
+		"CASCADE_FAILURE_BOOST=0.5",
+	)
+	state := func() incidentStatus {
+		t.Helper()
+		_, body := app.get("/admin/incident")
+		var s incidentStatus
+		if err := json.Unmarshal([]byte(body), &s); err != nil {
+			t.Fatalf("bad JSON %q: %v", body, err)
+		}
+		return s
+	}
+
+	if s := state(); s.CascadeBoost != 0 || s.EffectiveFailureRate != 0.1 {
+		t.Fatalf("before any db failure: %+v, want no boost over 0.1", s)
+	}
+	if resp, _ := app.get("/work"); resp.StatusCode != http.StatusBadGateway {
+		t.Fatalf("status = %d, want 502 from the failed db query", resp.StatusCode)
+	}
+	s := state()
+	if s.CascadeBoost < 0.4 || s.EffectiveFailureRate < 0.5 {
+		t.Errorf("right after a db failure: %+v, want a boost near 0.5 on top of 0.1", s)
+	}
+	time.Sleep(1100 * time.Millisecond)
+	if s := state(); s.CascadeBoost != 0 || s.EffectiveFailureRate != 0.1 {
+		t.Errorf("after the cascade window: %+v, want back to 0.1", s)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var requestsByCountry = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "http_requests_by_country_total",
+		Help: "Requests by client country, as resolved by the geo lookup",
+	},
+	[]string{"country"},
+)
+
+// geoLookup maps a client address to a country code. Real deployments would
+// plug in a GeoIP database here; the label set must stay small either way.
+type geoLookup func(netip.Addr) string
+
+var lookupCountry geoLookup = stubGeoLookup
+
+// stubGeoLookup only tells private from public addresses
+func stubGeoLookup(addr netip.Addr) string {
+	if addr.IsLoopback() || addr.IsPrivate() {
+		return "private"
+	}
+	return "unknown"
+}
+
+// withClientInfo records the client address on the server span and counts
+// the request under its country. The address only goes on the span: as a
+// metric label it would be unbounded, and it's personal data.
+func withClientInfo(next http.Handler) http.Handler {
+	if !cfg.ClientInfoEnabled {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		country := "unknown"
+		if addr, ok := clientAddr(r); ok {
+			trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("client.address", addr.String()))
+			country = lookupCountry(addr)
+		}
+		requestsByCountry.WithLabelValues(country).Inc()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientAddr walks X-Forwarded-For from the right for as long as the hop
+// that added the entry is a trusted proxy. Anything left of the first
+// untrusted hop could have been forged by the client.
+func clientAddr(r *http.Request) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	addr = addr.Unmap()
+
+	var hops []string
+	for _, v := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0 && trustedProxy(addr); i-- {
+		next, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		addr = next.Unmap()
+	}
+	return addr, true
+}
+
+func trustedProxy(addr netip.Addr) bool {
+	for _, p := range cfg.TrustedProxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
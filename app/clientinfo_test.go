@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestClientAddressThroughTrustedProxies(t *testing.T) {
+	app := startApp(t, "CLIENT_INFO_ENABLED=true", "TRUSTED_PROXIES=127.0.0.1,10.0.0.0/8")
+
+	// The left-most entry could be forged; the walk stops at the first
+	// untrusted hop, 203.0.113.7
+	app.get("/healthz", "X-Forwarded-For", "198.51.100.1, 203.0.113.7, 10.0.0.5")
+	span := app.spansNamed("healthz", 1)[0]
+	if v := span.attr("client.address"); v != "203.0.113.7" {
+		t.Errorf("client.address = %v, want 203.0.113.7", v)
+	}
+	if v := app.metricValue("http_requests_by_country_total", "country", "unknown"); v != 1 {
+		t.Errorf("requests for country unknown = %v, want 1", v)
+	}
+}
+
+func TestClientAddressIgnoresUntrustedForwardedFor(t *testing.T) {
+	app := startApp(t, "CLIENT_INFO_ENABLED=true")
+
+	app.get("/healthz", "X-Forwarded-For", "203.0.113.7")
+	span := app.spansNamed("healthz", 1)[0]
+	if v := span.attr("client.address"); v != "127.0.0.1" {
+		t.Errorf("client.address = %v, want the peer 127.0.0.1", v)
+	}
+	if v := app.metricValue("http_requests_by_country_total", "country", "private"); v != 1 {
+		t.Errorf("requests for country private = %v, want 1", v)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var downstreamConnections = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "downstream_connections_total",
+		Help: "Connections used for downstream requests, by whether they were reused from the pool",
+	},
+	[]string{"reused"},
+)
+
+// withClientTrace adds span events for the connection-level stages of an
+// outbound request: DNS, connect, TLS, and the first response byte. On a
+// reused connection only got_conn and first_byte show up, which is the point.
+func withClientTrace(ctx context.Context, span trace.Span) context.Context {
+	start := time.Now()
+	since := func() attribute.KeyValue {
+		return attribute.Int64("elapsed_us", time.Since(start).Microseconds())
+	}
+	event := func(name string, attrs ...attribute.KeyValue) {
+		span.AddEvent(name, trace.WithAttributes(append(attrs, since())...))
+	}
+	failed := func(err error) []attribute.KeyValue {
+		if err == nil {
+			return nil
+		}
+		return []attribute.KeyValue{attribute.String("exception.message", err.Error())}
+	}
+
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(info httptrace.DNSStartInfo) {
+			event("dns_start", attribute.String("net.host.name", info.Host))
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			event("dns_done", attribute.Int("dns.addresses", len(info.Addrs)))
+		},
+		ConnectStart: func(network, addr string) {
+			event("connect_start", attribute.String("net.peer.addr", addr))
+		},
+		ConnectDone: func(network, addr string, err error) {
+			event("connect_done", failed(err)...)
+		},
+		TLSHandshakeStart: func() {
+			event("tls_handshake_start")
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			event("tls_handshake_done", append(failed(err),
+				attribute.String("tls.protocol.version", tls.VersionName(state.Version)),
+			)...)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			downstreamConnections.WithLabelValues(strconv.FormatBool(info.Reused)).Inc()
+			event("got_conn", attribute.Bool("net.conn.reused", info.Reused))
+		},
+		GotFirstResponseByte: func() {
+			event("first_byte")
+		},
+	})
+}
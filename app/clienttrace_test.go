@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// downstreamEvents returns the span event names of each downstream attempt,
+// in the order the attempts were exported
+func downstreamEvents(app *testApp, n int) [][]string {
+	var out [][]string
+	for _, s := range app.spansNamed("GET", n) {
+		var names []string
+		for _, e := range s.Events {
+			names = append(names, e.Name)
+		}
+		out = append(out, names)
+	}
+	return out
+}
+
+func TestClientTraceEvents(t *testing.T) {
+	stub := startStub(t, http.StatusOK)
+	// A host name rather than an IP, so there's a DNS lookup to trace
+	url := strings.Replace(stub.URL, "127.0.0.1", "localhost", 1)
+	app := startApp(t, "DOWNSTREAM_URL="+url)
+
+	app.get("/work")
+	app.get("/work")
+	events := downstreamEvents(app, 2)
+
+	// localhost may resolve to more than one address, each dialled in turn
+	for _, name := range []string{"dns_start", "dns_done", "connect_start", "connect_done", "got_conn", "first_byte"} {
+		if !slices.Contains(events[0], name) {
+			t.Errorf("first call events %v lack %s", events[0], name)
+		}
+	}
+	// The second call reuses the pooled connection
+	if want := []string{"got_conn", "first_byte"}; !slices.Equal(events[1], want) {
+		t.Errorf("second call events = %v, want %v", events[1], want)
+	}
+	if v := app.metricValue("downstream_connections_total", "reused", "true"); v != 1 {
+		t.Errorf("reused connections = %v, want 1", v)
+	}
+	if v := app.metricValue("downstream_connections_total", "reused", "false"); v != 1 {
+		t.Errorf("new connections = %v, want 1", v)
+	}
+}
+
+func TestClientTraceTLSHandshake(t *testing.T) {
+	stub := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer stub.Close()
+	app := startApp(t, "DOWNSTREAM_URL="+stub.URL, "DOWNSTREAM_MAX_RETRIES=0")
+
+	// The app doesn't trust the test certificate, so the handshake fails,
+	// but both of its events are still recorded
+	app.get("/work")
+	events := downstreamEvents(app, 1)[0]
+	for _, name := range []string{"connect_done", "tls_handshake_start", "tls_handshake_done"} {
+		if !slices.Contains(events, name) {
+			t.Errorf("events %v lack %s", events, name)
+		}
+	}
+	if slices.Contains(events, "first_byte") {
+		t.Errorf("events %v have first_byte after a failed handshake", events)
+	}
+}
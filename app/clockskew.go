@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// The SDK has no pluggable clock, so CLOCK_SKEW is applied on the way out:
+// every exported span, and its events, is shifted by the skew. To a backend
+// the service then looks like it runs on a misaligned clock, which is how
+// children start "before" their parents across services.
+type skewExporter struct {
+	sdktrace.SpanExporter
+	skew time.Duration
+}
+
+// withClockSkew wraps exp so it exports spans shifted by cfg.ClockSkew
+func withClockSkew(exp sdktrace.SpanExporter) sdktrace.SpanExporter {
+	if cfg.ClockSkew == 0 {
+		return exp
+	}
+	return skewExporter{exp, cfg.ClockSkew}
+}
+
+func (e skewExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	skewed := make([]sdktrace.ReadOnlySpan, len(spans))
+	for i, s := range spans {
+		skewed[i] = skewedSpan{s, e.skew}
+	}
+	return e.SpanExporter.ExportSpans(ctx, skewed)
+}
+
+type skewedSpan struct {
+	sdktrace.ReadOnlySpan
+	skew time.Duration
+}
+
+func (s skewedSpan) StartTime() time.Time { return s.ReadOnlySpan.StartTime().Add(s.skew) }
+func (s skewedSpan) EndTime() time.Time   { return s.ReadOnlySpan.EndTime().Add(s.skew) }
+
+func (s skewedSpan) Events() []sdktrace.Event {
+	events := s.ReadOnlySpan.Events()
+	out := make([]sdktrace.Event, len(events))
+	for i, ev := range events {
+		ev.Time = ev.Time.Add(s.skew)
+		out[i] = ev
+	}
+	return out
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockSkewShiftsSpanTimestamps(t *testing.T) {
+	const skew = time.Hour
+	app := startApp(t, "CLOCK_SKEW=1h", "OTEL_BSP_SCHEDULE_DELAY=50")
+
+	before := time.Now()
+	app.get("/work")
+	after := time.Now()
+
+	inWindow := func(ts time.Time) bool {
+		return !ts.Before(before.Add(skew)) && !ts.After(after.Add(skew))
+	}
+	span := app.spansNamed("work", 1)[0]
+	if !inWindow(span.StartTime) || !inWindow(span.EndTime) {
+		t.Errorf("span ran %v - %v, want within %v - %v", span.StartTime, span.EndTime, before.Add(skew), after.Add(skew))
+	}
+	for _, e := range span.Events {
+		if !inWindow(e.Time) {
+			t.Errorf("event %s at %v is not skewed", e.Name, e.Time)
+		}
+	}
+
+	// The OTLP copy is skewed the same way
+	var otlpStart time.Time
+	app.waitFor("the work span over OTLP", func() bool {
+		for _, rs := range app.collector.resourceSpans() {
+			for _, ss := range rs.GetScopeSpans() {
+				for _, s := range ss.GetSpans() {
+					if s.GetName() == "work" {
+						otlpStart = time.Unix(0, int64(s.GetStartTimeUnixNano()))
+						return true
+					}
+				}
+			}
+		}
+		return false
+	})
+	if !inWindow(otlpStart) {
+		t.Errorf("OTLP span starts at %v, want within %v - %v", otlpStart, before.Add(skew), after.Add(skew))
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var coldStartSeconds = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "cold_start_seconds",
+		Help: "Extra latency the first request after startup paid for the simulated cold start",
+	},
+)
+
+// Set once the first request has claimed the cold start
+var coldStartTaken atomic.Bool
+
+// withColdStart delays the first request to the app's own routes by
+// COLD_START_LATENCY in a "cold_start" span, the way a serverless runtime
+// initializes on its first invocation. Every later request is warm.
+func withColdStart(next http.Handler, route string) http.Handler {
+	if cfg.ColdStartLatency <= 0 || route == "healthz" || route == "readyz" || strings.HasPrefix(route, "admin_") {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		cold := coldStartTaken.CompareAndSwap(false, true)
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("faas.coldstart", cold))
+		if cold {
+			start := time.Now()
+			// Created at every SPAN_DETAIL: it's what explains the slow request
+			_, span := otel.Tracer("app").Start(ctx, "cold_start", trace.WithSpanKind(trace.SpanKindInternal))
+			sleepCtx(ctx, cfg.ColdStartLatency)
+			span.End()
+			coldStartSeconds.Set(time.Since(start).Seconds())
+			requestLogger(ctx).Info("cold start", "latency_ms", time.Since(start).Milliseconds())
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestColdStartOnFirstRequest(t *testing.T) {
+	// Longer than /work's own simulated latency (at most 720ms) can be
+	app := startApp(t, "COLD_START_LATENCY=800ms")
+	app.get("/healthz") // probes don't use up the cold start
+	app.get("/work")
+	app.get("/work")
+
+	work := app.spansNamed("work", 2)
+	first, second := work[0], work[1]
+	if first.StartTime.After(second.StartTime) {
+		first, second = second, first
+	}
+	if d := first.EndTime.Sub(first.StartTime); d < 800*time.Millisecond {
+		t.Errorf("first request took %v, want at least the 800ms cold start", d)
+	}
+	if first.EndTime.Sub(first.StartTime) <= second.EndTime.Sub(second.StartTime) {
+		t.Error("the first request wasn't slower than the second")
+	}
+	if first.attr("faas.coldstart") != true || second.attr("faas.coldstart") != false {
+		t.Errorf("faas.coldstart = %v then %v, want true then false", first.attr("faas.coldstart"), second.attr("faas.coldstart"))
+	}
+
+	cold := app.spansNamed("cold_start", 1)
+	if len(cold) != 1 || cold[0].Parent.SpanID != first.SpanContext.SpanID {
+		t.Errorf("want one cold_start span, under the first request; got %d", len(cold))
+	}
+	for _, s := range app.children(second) {
+		if s.Name == "cold_start" {
+			t.Error("the second request has a cold_start span")
+		}
+	}
+	if v := app.metricValue("cold_start_seconds"); v < 0.8 {
+		t.Errorf("cold_start_seconds = %v, want at least 0.8", v)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+)
+
+// fakeCollector is an in-process OTLP/gRPC receiver that keeps everything
+// it is sent
+type fakeCollector struct {
+	addr string
+	srv  *grpc.Server
+
+	// When set, trace exports block until it is closed
+	hold chan struct{}
+
+	mu      sync.Mutex
+	traces  []*tracepb.ResourceSpans
+	metrics []*metricspb.ResourceMetrics
+	logs    []*logspb.ResourceLogs
+}
+
+func startCollector(t *testing.T) *fakeCollector {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &fakeCollector{addr: ln.Addr().String(), srv: grpc.NewServer()}
+	coltracepb.RegisterTraceServiceServer(c.srv, traceReceiver{c: c})
+	colmetricspb.RegisterMetricsServiceServer(c.srv, metricsReceiver{c: c})
+	collogspb.RegisterLogsServiceServer(c.srv, logsReceiver{c: c})
+	go c.srv.Serve(ln)
+	t.Cleanup(c.srv.Stop)
+	return c
+}
+
+// The three services share a method name, so each gets its own receiver
+type traceReceiver struct {
+	coltracepb.UnimplementedTraceServiceServer
+	c *fakeCollector
+}
+
+func (r traceReceiver) Export(ctx context.Context, req *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
+	if r.c.hold != nil {
+		select {
+		case <-r.c.hold:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	r.c.mu.Lock()
+	defer r.c.mu.Unlock()
+	r.c.traces = append(r.c.traces, req.GetResourceSpans()...)
+	return &coltracepb.ExportTraceServiceResponse{}, nil
+}
+
+type metricsReceiver struct {
+	colmetricspb.UnimplementedMetricsServiceServer
+	c *fakeCollector
+}
+
+func (r metricsReceiver) Export(ctx context.Context, req *colmetricspb.ExportMetricsServiceRequest) (*colmetricspb.ExportMetricsServiceResponse, error) {
+	r.c.mu.Lock()
+	defer r.c.mu.Unlock()
+	r.c.metrics = append(r.c.metrics, req.GetResourceMetrics()...)
+	return &colmetricspb.ExportMetricsServiceResponse{}, nil
+}
+
+type logsReceiver struct {
+	collogspb.UnimplementedLogsServiceServer
+	c *fakeCollector
+}
+
+func (r logsReceiver) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	r.c.mu.Lock()
+	defer r.c.mu.Unlock()
+	r.c.logs = append(r.c.logs, req.GetResourceLogs()...)
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
+
+func (c *fakeCollector) resourceMetrics() []*metricspb.ResourceMetrics {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*metricspb.ResourceMetrics(nil), c.metrics...)
+}
+
+func (c *fakeCollector) resourceSpans() []*tracepb.ResourceSpans {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*tracepb.ResourceSpans(nil), c.traces...)
+}
+
+func (c *fakeCollector) resourceLogs() []*logspb.ResourceLogs {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*logspb.ResourceLogs(nil), c.logs...)
+}
+
+// metricScopes maps every metric received to the scope that produced it
+func (c *fakeCollector) metricScopes() map[string]string {
+	scopes := map[string]string{}
+	for _, rm := range c.resourceMetrics() {
+		for _, sm := range rm.GetScopeMetrics() {
+			for _, m := range sm.GetMetrics() {
+				scopes[m.GetName()] = sm.GetScope().GetName()
+			}
+		}
+	}
+	return scopes
+}
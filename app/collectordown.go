@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+var exportFailures = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "otlp_export_failures_total",
+		Help: "OTLP exports that failed, real or simulated through /admin/simulate-collector-down",
+	},
+	[]string{"signal"},
+)
+
+// Set while /admin/simulate-collector-down has the collector "down"
+var collectorDown atomic.Bool
+
+var errCollectorDown = errors.New("collector down (simulated)")
+
+// faultySpanExporter fails every export while collectorDown is set, as if
+// the collector had gone away, and counts failures either way
+type faultySpanExporter struct {
+	sdktrace.SpanExporter
+}
+
+func (e faultySpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	err := errCollectorDown
+	if !collectorDown.Load() {
+		err = e.SpanExporter.ExportSpans(ctx, spans)
+	}
+	if err != nil {
+		exportFailures.WithLabelValues("traces").Inc()
+	}
+	return err
+}
+
+// faultyMetricExporter is faultySpanExporter for metrics
+type faultyMetricExporter struct {
+	sdkmetric.Exporter
+}
+
+func (e faultyMetricExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	err := errCollectorDown
+	if !collectorDown.Load() {
+		err = e.Exporter.Export(ctx, rm)
+	}
+	if err != nil {
+		exportFailures.WithLabelValues("metrics").Inc()
+	}
+	return err
+}
+
+type collectorDownStatus struct {
+	Down bool `json:"down"`
+}
+
+// simulateCollectorDownHandler makes every OTLP export fail on POST, until
+// DELETE; GET reports the state. The SDK handles the failures as it would a
+// real outage, so its retry and drop behaviour can be watched safely.
+func simulateCollectorDownHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		collectorDown.Store(true)
+		logger.Warn("simulated collector outage started")
+	case http.MethodDelete:
+		collectorDown.Store(false)
+		logger.Info("simulated collector outage stopped")
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(collectorDownStatus{Down: collectorDown.Load()})
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+// otlpSpanCount counts the spans the collector received under name
+func otlpSpanCount(c *fakeCollector, name string) int {
+	n := 0
+	for _, rs := range c.resourceSpans() {
+		for _, ss := range rs.GetScopeSpans() {
+			for _, s := range ss.GetSpans() {
+				if s.GetName() == name {
+					n++
+				}
+			}
+		}
+	}
+	return n
+}
+
+func TestSimulatedCollectorDown(t *testing.T) {
+	app := startApp(t, "OTEL_BSP_SCHEDULE_DELAY=50", "OTEL_METRIC_EXPORT_INTERVAL=200")
+
+	app.do(http.MethodPost, "/admin/simulate-collector-down", nil)
+	app.get("/work")
+	app.waitFor("failed trace and metric exports", func() bool {
+		f := app.scrape()
+		traces, _ := seriesValue(f, "otlp_export_failures_total", "signal", "traces")
+		metrics, _ := seriesValue(f, "otlp_export_failures_total", "signal", "metrics")
+		return traces > 0 && metrics > 0
+	})
+	if n := otlpSpanCount(app.collector, "work"); n != 0 {
+		t.Errorf("collector got %d work spans while down", n)
+	}
+
+	app.do(http.MethodDelete, "/admin/simulate-collector-down", nil)
+	failed := app.metricValue("otlp_export_failures_total", "signal", "traces")
+	app.get("/work")
+	// The first work span may still have been queued when the collector
+	// came back, in which case it goes out too
+	app.waitFor("the work span after recovery", func() bool {
+		return otlpSpanCount(app.collector, "work") >= 1
+	})
+	if v := app.metricValue("otlp_export_failures_total", "signal", "traces"); v != failed {
+		t.Errorf("trace export failures went from %v to %v after recovering", failed, v)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var collectorReachableGauge = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "collector_reachable",
+		Help: "1 if the OTLP collector endpoint accepted a connection on the last health poll",
+	},
+	[]string{"endpoint"},
+)
+
+var collectorHealthyGauge = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "collector_healthy",
+		Help: "1 if the collector's health_check extension reported healthy on the last poll (only with COLLECTOR_HEALTH_URL)",
+	},
+)
+
+// True while every configured collector answered the last poll, and the
+// health_check extension (if configured) said it was healthy
+var collectorsReachable atomic.Bool
+
+const collectorDialTimeout = 2 * time.Second
+
+var healthCheckClient = &http.Client{Timeout: collectorDialTimeout}
+
+// pollCollectors dials every OTLP endpoint on each interval, so an outage of
+// the telemetry backend shows up before spans start piling up in the queue
+func pollCollectors(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		checkCollectors()
+		<-ticker.C
+	}
+}
+
+func checkCollectors() {
+	_, span := startBackground("collector_health")
+	defer span.End()
+
+	// Dial the endpoints in parallel on the background pool
+	var mu sync.Mutex
+	all := true
+	var dials []func()
+	for _, endpoint := range collectorEndpoints() {
+		dials = append(dials, func() {
+			conn, err := net.DialTimeout("tcp", endpoint, collectorDialTimeout)
+			if err != nil {
+				mu.Lock()
+				all = false
+				mu.Unlock()
+				collectorReachableGauge.WithLabelValues(endpoint).Set(0)
+				logger.Warn("collector unreachable", "endpoint", endpoint, "error", err)
+				return
+			}
+			conn.Close()
+			collectorReachableGauge.WithLabelValues(endpoint).Set(1)
+		})
+	}
+	backgroundPool.run(dials...)
+
+	if cfg.CollectorHealthURL != "" {
+		if err := checkHealthExtension(cfg.CollectorHealthURL); err != nil {
+			all = false
+			collectorHealthyGauge.Set(0)
+			logger.Warn("collector unhealthy", "url", redactURL(cfg.CollectorHealthURL), "error", err)
+		} else {
+			collectorHealthyGauge.Set(1)
+		}
+	}
+	collectorsReachable.Store(all)
+	span.SetAttributes(attribute.Bool("collector.reachable", all))
+}
+
+// collectorEndpoints lists every collector some signal is exported to, once
+func collectorEndpoints() []string {
+	endpoints := slices.Concat(cfg.OTLPTraceEndpoints, cfg.OTLPMetricEndpoints)
+	if cfg.LogsExporter == "otlp" {
+		endpoints = append(endpoints, cfg.OTLPLogEndpoints...)
+	}
+	var all []string
+	for _, endpoint := range endpoints {
+		if !slices.Contains(all, endpoint) {
+			all = append(all, endpoint)
+		}
+	}
+	return all
+}
+
+// checkHealthExtension queries the collector's health_check extension, which
+// answers 200 while the collector's pipelines are up and 503 otherwise. A
+// collector can accept connections and still be unhealthy, e.g. when its
+// own exporters are failing.
+func checkHealthExtension(url string) error {
+	resp, err := healthCheckClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("health check returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// closedAddr returns a loopback address nothing is listening on
+func closedAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return addr
+}
+
+func TestCollectorHealthOffByDefault(t *testing.T) {
+	app := startApp(t)
+
+	// A poll would run straight away, well within this
+	time.Sleep(300 * time.Millisecond)
+	if _, ok := seriesValue(app.scrape(), "collector_reachable", "endpoint", app.collector.addr); ok {
+		t.Error("collectors were dialed without COLLECTOR_HEALTH_INTERVAL")
+	}
+}
+
+func TestCollectorDownFailsReadiness(t *testing.T) {
+	down := closedAddr(t)
+	app := startApp(t,
+		"OTLP_ENDPOINTS="+down,
+		"COLLECTOR_HEALTH_INTERVAL=100ms",
+		"COLLECTOR_REQUIRED_FOR_READY=true",
+	)
+
+	app.waitFor("the collector_reachable gauge", func() bool {
+		_, ok := seriesValue(app.scrape(), "collector_reachable", "endpoint", down)
+		return ok
+	})
+	if v := app.metricValue("collector_reachable", "endpoint", down); v != 0 {
+		t.Errorf("collector_reachable = %v for a down collector, want 0", v)
+	}
+	if resp, _ := app.get("/readyz"); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("readyz = %d, want 503", resp.StatusCode)
+	}
+}
+
+func TestCollectorUpPassesReadiness(t *testing.T) {
+	app := startApp(t, "COLLECTOR_HEALTH_INTERVAL=100ms", "COLLECTOR_REQUIRED_FOR_READY=true")
+
+	app.waitFor("the collector_reachable gauge", func() bool {
+		v, _ := seriesValue(app.scrape(), "collector_reachable", "endpoint", app.collector.addr)
+		return v == 1
+	})
+	if resp, _ := app.get("/readyz"); resp.StatusCode != http.StatusOK {
+		t.Errorf("readyz = %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestCollectorDownWithoutReadinessGate(t *testing.T) {
+	app := startApp(t, "OTLP_ENDPOINTS="+closedAddr(t), "COLLECTOR_HEALTH_INTERVAL=100ms")
+
+	app.logsWithMsg("collector unreachable")
+	if resp, _ := app.get("/readyz"); resp.StatusCode != http.StatusOK {
+		t.Errorf("readyz = %d without COLLECTOR_REQUIRED_FOR_READY, want 200", resp.StatusCode)
+	}
+}
+
+func TestUnhealthyCollectorFailsReadiness(t *testing.T) {
+	health := startStub(t, http.StatusServiceUnavailable)
+	app := startApp(t,
+		"COLLECTOR_HEALTH_URL="+health.URL,
+		"COLLECTOR_HEALTH_INTERVAL=100ms",
+		"COLLECTOR_REQUIRED_FOR_READY=true",
+	)
+
+	app.logsWithMsg("collector unhealthy")
+	if resp, _ := app.get("/readyz"); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("readyz = %d with an unhealthy collector, want 503", resp.StatusCode)
+	}
+	if v := app.metricValue("collector_healthy"); v != 0 {
+		t.Errorf("collector_healthy = %v, want 0", v)
+	}
+	// The collector itself still takes connections
+	if v := app.metricValue("collector_reachable", "endpoint", app.collector.addr); v != 1 {
+		t.Errorf("collector_reachable = %v, want 1", v)
+	}
+}
+
+func TestHealthyCollectorPassesReadiness(t *testing.T) {
+	health := startStub(t, http.StatusOK)
+	app := startApp(t,
+		"COLLECTOR_HEALTH_URL="+health.URL,
+		"COLLECTOR_HEALTH_INTERVAL=100ms",
+		"COLLECTOR_REQUIRED_FOR_READY=true",
+	)
+
+	app.waitFor("a health poll", func() bool { return len(health.requests()) > 0 })
+	app.waitFor("readiness", func() bool {
+		resp, _ := app.get("/readyz")
+		return resp.StatusCode == http.StatusOK
+	})
+	if v := app.metricValue("collector_healthy"); v != 1 {
+		t.Errorf("collector_healthy = %v, want 1", v)
+	}
+}
@@ -0,0 +1,1162 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds the runtime settings of the sample app, resolved from the
+// environment at startup.
+type Config struct {
+	// Identifies the running release on the resource and on every
+	// Prometheus series
+	DeploymentID string
+
+	// Simulated region: a resource attribute and metric label, plus the
+	// base latency RegionLatencies lists for it
+	Region          string
+	RegionLatencies map[string]time.Duration
+
+	ListenAddr     string
+	MaxHeaderBytes int
+
+	// tcp listens dual-stack where the OS allows it; tcp4/tcp6 pin a family
+	ListenNetwork string
+
+	// Bind with SO_REUSEPORT (Linux only), so a replacement instance can
+	// start listening before this one has drained
+	ReusePort bool
+
+	// Also accept cleartext HTTP/2 (h2c)
+	H2C bool
+
+	// Address of the optional gRPC server; empty leaves it off
+	GRPCListenAddr string
+
+	// Largest request body accepted; bigger ones get a 413
+	MaxBodyBytes int64
+
+	// Where log records go when stdout can't be written to
+	LogFallback string
+
+	// Queue log records for a background writer instead of writing them
+	// inline (0 = synchronous)
+	LogAsyncBuffer   int
+	LogFlushInterval time.Duration
+
+	// Per-request access log; non-error responses are sampled
+	AccessLog            bool
+	AccessLogSampleRatio float64
+
+	// Log an audit record for every /admin/* call
+	AdminAuditLog bool
+
+	// Record the client address on spans, trusting X-Forwarded-For only when
+	// the hop that sent it is in TrustedProxies
+	ClientInfoEnabled bool
+	TrustedProxies    []netip.Prefix
+
+	// Headers recorded as span attributes; nothing is unless listed
+	CaptureRequestHeaders  []string
+	CaptureResponseHeaders []string
+
+	// Connection-level limits; without them a slow client can hold a
+	// connection open forever
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+
+	LameDuckDuration time.Duration
+	ShutdownTimeout  time.Duration
+
+	// Time each telemetry provider gets to export what it holds on exit
+	ShutdownFlushTimeout time.Duration
+
+	// How paths that only differ from a route by case or a trailing slash
+	// are handled: "off" (404), "rewrite" or "redirect"
+	PathNormalization string
+
+	// Put the trace ID in the body of 500s from recovered panics; for
+	// development, not production
+	PanicTraceInBody bool
+
+	// Requests slower than this get a "slow request" warning (0 = off)
+	SlowRequestThreshold time.Duration
+
+	// Requests served at once before shedding load with 503 (0 = unlimited)
+	MaxConcurrentRequests int
+
+	// Where the Prometheus exposition is served
+	MetricsPath string
+
+	// Attach trace exemplars to the request histogram; turning this off also
+	// restricts /metrics to the classic text format
+	EnableExemplars bool
+
+	// How long a bucket's exemplar is kept before a newer request may replace it
+	ExemplarMinAge time.Duration
+
+	// Which requests get exemplars: all of them, or only 5xx ones
+	ExemplarMode string
+
+	// Add a log_id label to exemplars, matching one on the request's log line
+	ExemplarLogID bool
+
+	// Also record the request histogram as a native histogram
+	NativeHistograms bool
+
+	// Also record the request histogram under OTel's dotted UTF-8 name
+	UTF8MetricNames bool
+
+	// Create the request metrics' series at zero on start-up
+	MetricsWarmup bool
+
+	// Labels left off the request metrics, collapsing their series
+	DropRequestLabels []string
+
+	// runtime/metrics classes exported on top of the default Go metrics
+	GoRuntimeMetrics []string
+
+	// Record http_request_duration_summary alongside the histogram, with
+	// quantile -> allowed error objectives
+	RequestSummaryEnabled    bool
+	RequestSummaryObjectives map[float64]float64
+
+	// Expose key config values as config_* gauges
+	ConfigMetrics bool
+
+	// Measure the time each request spends in span and metric calls
+	InstrumentationOverhead bool
+
+	// Availability objective for /work, and the window the error budget
+	// burn rate is computed over
+	SLOTarget     float64
+	SLOBurnWindow time.Duration
+
+	// Apdex target T for /work (0 = off), and the window apdex_score covers
+	ApdexTarget time.Duration
+	ApdexWindow time.Duration
+
+	// Freeze the request clock and use sequential trace IDs so /metrics is
+	// byte-stable, for golden-file tests
+	TestDeterministic bool
+
+	// File the final Prometheus exposition is written to on shutdown
+	MetricsDumpFile string
+
+	// Deadline for each request, by route name, falling back to
+	// RequestTimeout; exceeding it returns a 504
+	RequestTimeout time.Duration
+	RouteTimeouts  map[string]time.Duration
+
+	SpanDetail spanDetail
+
+	// Spans open longer than this are reported as leaks (0 = disabled)
+	MaxSpanDuration time.Duration
+
+	// Root span durations kept for the percentile gauges (0 = disabled)
+	SpanPercentileSamples int
+
+	// Give each background task iteration its own root span
+	TraceBackgroundTasks bool
+
+	// Offset added to every exported span timestamp, to simulate a host
+	// whose clock is off
+	ClockSkew time.Duration
+
+	// Span attributes whose key contains one of these (case-insensitive)
+	// are masked, or removed with ScrubMode "remove", before export
+	ScrubAttributes []string
+	ScrubMode       string
+
+	// Attribute values that mark a span's trace for keeping by the
+	// collector's tail sampler, as key -> accepted values
+	KeepAttributes map[string][]string
+
+	// Attributes stamped onto every span at start, as key -> value
+	GlobalSpanAttributes map[string]string
+
+	// Feature flags for the in-process evaluator, as flag -> weighted variants
+	FeatureFlags map[string][]flagVariant
+
+	// Stamp server spans with a route+status trace.group fingerprint
+	TraceFingerprint bool
+
+	// Optional HTTP dependency called from /work
+	DownstreamURL           string
+	DownstreamPeerService   string
+	DownstreamMaxRetries    int
+	DownstreamRetryBackoff  time.Duration
+	DownstreamMaxRetryAfter time.Duration
+	DownstreamConnectDelay  time.Duration
+
+	// Token bucket limiting downstream retries across all requests:
+	// tokens per second (0 = unlimited) and bucket size
+	RetryBudgetRate  float64
+	RetryBudgetBurst int
+
+	// Built-in client sending requests with a fresh traceparent to
+	// UpstreamURL (default: this server's /work), UpstreamRate per second
+	UpstreamRate    float64
+	UpstreamURL     string
+	UpstreamBaggage string
+
+	// Context propagation formats for incoming and outgoing requests
+	Propagators         []string
+	OutboundPropagators []string
+
+	// Honor X-Debug: debug logs and forced sampling for that request only
+	DebugHeaderEnabled bool
+
+	// Header and format of the "custom" propagator
+	CustomTraceHeader string
+	CustomTraceFormat string
+
+	// Also export spans as JSON: "off", "memory" (only kept for
+	// /admin/last-span-json) or "stdout"
+	SpanJSONExport string
+
+	// OTLP/gRPC collectors that every trace and metric is exported to
+	OTLPEndpoints []string
+
+	// Per-signal collectors, falling back to OTLPEndpoints
+	OTLPTraceEndpoints  []string
+	OTLPMetricEndpoints []string
+	OTLPLogEndpoints    []string
+
+	// "otlp" also exports logs over OTLP; "none" keeps them on stdout only
+	LogsExporter string
+
+	// How often to dial the collectors (0 = never), and whether /readyz
+	// should fail while one is unreachable
+	CollectorHealthInterval   time.Duration
+	CollectorRequiredForReady bool
+
+	// Goroutines shared by background tasks such as the collector checks
+	BackgroundWorkers int
+
+	// The collector's health_check extension, polled alongside the dials
+	CollectorHealthURL string
+
+	// Mirrors the SDK's own switch for its experimental self-diagnostics
+	SDKObservability bool
+
+	// Flag a suspected goroutine leak when the count grows by at least
+	// GoroutineLeakMinGrowth without ever dropping over the window
+	GoroutineLeakWindow    time.Duration
+	GoroutineLeakMinGrowth int
+
+	// Trace sampler, as read by the SDK itself from OTEL_TRACES_SAMPLER(_ARG)
+	Sampler    string
+	SamplerArg string
+
+	// Span batches exported at once across all exporters (0 = unbounded)
+	ExportMaxConcurrency int
+
+	// Headers sent with every OTLP export; often carry API keys
+	OTLPHeaders string
+
+	// Fraction of /work requests that fail with a 500
+	FailureRate float64
+
+	// Failure rates by route name; /work falls back to FailureRate, and
+	// other routes only fail when listed
+	RouteFailureRates map[string]float64
+
+	// Fraction of successful /work requests that also log a simulated warning
+	WarnRate float64
+
+	// Delay /echo-body adds per KiB of payload
+	EchoDelayPerKB time.Duration
+
+	// Size of the payload /range serves slices of
+	RangePayloadBytes int
+
+	// Shape of incidents started through /admin/incident: peak failure rate
+	// and extra latency, decaying back to baseline over the duration
+	IncidentDuration    time.Duration
+	IncidentFailureRate float64
+	IncidentLatency     time.Duration
+
+	// Recurring error/latency anomalies at fixed wall-clock times, at the
+	// incident's peak failure rate and latency
+	AnomalySchedule []anomalyWindow
+
+	// Slope, per second, of the synthetic_ramp_value gauge (0 = no gauge),
+	// and where it starts
+	RampRate  float64
+	RampStart float64
+
+	// Default ramp for /admin/degrade, and the 503 share it ramps up to
+	DegradeRamp     time.Duration
+	DegradeMaxRatio float64
+
+	// Fraction of simulated cache lookups that hit; misses go to the "db"
+	CacheHitRatio float64
+
+	// How often the background evictor runs (0 = never)
+	CacheEvictionInterval time.Duration
+
+	// Fraction of db queries (cache misses) that fail. Each failure raises
+	// the failure rate by up to CascadeFailureBoost for CascadeWindow.
+	DBFailureRate       float64
+	CascadeWindow       time.Duration
+	CascadeFailureBoost float64
+
+	// Connections in the simulated database pool (0 = no pool)
+	DBPoolSize int
+
+	// Simulated authentication phase in front of the app's routes
+	AuthEnabled     bool
+	AuthLatency     time.Duration
+	AuthFailureRate float64
+
+	// One-time extra latency on the first request after startup (0 = off)
+	ColdStartLatency time.Duration
+
+	// Simulated gRPC dependency called from /work
+	GRPCDepEnabled   bool
+	GRPCDepErrorRate float64
+	GRPCDepErrorCode codes.Code
+
+	// Synthetic message queue with a background producer and consumer
+	MQEnabled     bool
+	MQProduceRate float64
+	MQProcessTime time.Duration
+	MQQueueSize   int
+}
+
+var cfg Config
+
+// Settings read from CONFIG_FILE, keyed by environment variable name. Any
+// variable actually set in the environment takes precedence.
+var fileSettings map[string]string
+
+func loadConfig() Config {
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		settings, err := readConfigFile(path)
+		if err != nil {
+			log.Fatalf("failed to load CONFIG_FILE: %v", err)
+		}
+		fileSettings = settings
+	}
+
+	c := Config{
+		DeploymentID: envString("DEPLOYMENT_ID", ""),
+
+		Region: envString("REGION", ""),
+		RegionLatencies: envDurationMap("REGION_LATENCIES", map[string]time.Duration{
+			"us-east":  0,
+			"us-west":  30 * time.Millisecond,
+			"eu-west":  80 * time.Millisecond,
+			"ap-south": 150 * time.Millisecond,
+		}),
+
+		ListenAddr:     envString("LISTEN_ADDR", ":8080"),
+		MaxHeaderBytes: envInt("MAX_HEADER_BYTES", http.DefaultMaxHeaderBytes),
+
+		ListenNetwork: envChoice("LISTEN_NETWORK", "tcp", "tcp", "tcp4", "tcp6"),
+		ReusePort:     envBool("REUSE_PORT", false),
+		H2C:           envBool("H2C", false),
+
+		GRPCListenAddr: envString("GRPC_LISTEN_ADDR", ""),
+
+		MaxBodyBytes: int64(envInt("MAX_BODY_BYTES", 1<<20)),
+
+		LogFallback: envString("LOG_FALLBACK", ""),
+
+		LogAsyncBuffer:   envInt("LOG_ASYNC_BUFFER", 0),
+		LogFlushInterval: envDuration("LOG_FLUSH_INTERVAL", time.Second),
+
+		AccessLog:            envBool("ACCESS_LOG", false),
+		AccessLogSampleRatio: envFloat("ACCESS_LOG_SAMPLE_RATIO", 1),
+
+		AdminAuditLog: envBool("ADMIN_AUDIT_LOG", true),
+
+		ClientInfoEnabled: envBool("CLIENT_INFO_ENABLED", false),
+		TrustedProxies:    envPrefixes("TRUSTED_PROXIES"),
+
+		CaptureRequestHeaders:  envList("CAPTURE_REQUEST_HEADERS", nil),
+		CaptureResponseHeaders: envList("CAPTURE_RESPONSE_HEADERS", nil),
+
+		ReadHeaderTimeout: envDuration("HTTP_READ_HEADER_TIMEOUT", 5*time.Second),
+		ReadTimeout:       envDuration("HTTP_READ_TIMEOUT", 15*time.Second),
+		WriteTimeout:      envDuration("HTTP_WRITE_TIMEOUT", 30*time.Second),
+		IdleTimeout:       envDuration("HTTP_IDLE_TIMEOUT", 60*time.Second),
+
+		LameDuckDuration: envDuration("LAMEDUCK_DURATION", 0),
+		ShutdownTimeout:  envDuration("SHUTDOWN_TIMEOUT", 10*time.Second),
+
+		ShutdownFlushTimeout: envDuration("SHUTDOWN_FLUSH_TIMEOUT", 5*time.Second),
+
+		MaxConcurrentRequests: envInt("MAX_CONCURRENT_REQUESTS", 0),
+		SlowRequestThreshold:  time.Duration(envInt("SLOW_REQUEST_MS", 0)) * time.Millisecond,
+		PathNormalization:     envChoice("PATH_NORMALIZATION", "off", "off", "rewrite", "redirect"),
+
+		PanicTraceInBody: envBool("PANIC_TRACE_IN_BODY", false),
+
+		MetricsPath: envString("METRICS_PATH", "/metrics"),
+
+		EnableExemplars: envBool("ENABLE_EXEMPLARS", true),
+		ExemplarMinAge:  envDuration("EXEMPLAR_MIN_AGE", 0),
+		ExemplarMode:    envChoice("EXEMPLAR_MODE", "all", "all", "errors"),
+		ExemplarLogID:   envBool("EXEMPLAR_LOG_ID", false),
+
+		NativeHistograms: envBool("NATIVE_HISTOGRAMS", false),
+		GoRuntimeMetrics: envList("GO_RUNTIME_METRICS", nil),
+
+		UTF8MetricNames: envBool("UTF8_METRIC_NAMES", false),
+		MetricsWarmup:   envBool("METRICS_WARMUP", false),
+
+		DropRequestLabels: envPatterns("DROP_REQUEST_LABELS", nil),
+
+		TestDeterministic: envBool("TEST_DETERMINISTIC", false),
+
+		InstrumentationOverhead: envBool("INSTRUMENTATION_OVERHEAD", false),
+		ConfigMetrics:           envBool("CONFIG_METRICS", false),
+
+		SLOTarget:     envFloat("SLO_TARGET", 0.99),
+		SLOBurnWindow: envDuration("SLO_BURN_WINDOW", 5*time.Minute),
+
+		ApdexTarget: envDuration("APDEX_T", 0),
+		ApdexWindow: envDuration("APDEX_WINDOW", time.Minute),
+
+		RequestSummaryEnabled: envBool("REQUEST_SUMMARY_ENABLED", false),
+		RequestSummaryObjectives: envObjectives("REQUEST_SUMMARY_OBJECTIVES",
+			map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001}),
+
+		MetricsDumpFile: envString("METRICS_DUMP_FILE", ""),
+
+		RequestTimeout: envDuration("REQUEST_TIMEOUT", 5*time.Second),
+		RouteTimeouts:  envDurationMap("ROUTE_TIMEOUTS", map[string]time.Duration{}),
+
+		SpanDetail: envSpanDetail("SPAN_DETAIL", spanDetailFull),
+
+		MaxSpanDuration:      envDuration("MAX_SPAN_DURATION", 0),
+		TraceBackgroundTasks: envBool("TRACE_BACKGROUND_TASKS", false),
+		ClockSkew:            envDuration("CLOCK_SKEW", 0),
+
+		SpanPercentileSamples: envInt("SPAN_PERCENTILE_SAMPLES", 0),
+
+		ScrubAttributes: envPatterns("SCRUB_ATTRIBUTES", []string{"password", "authorization", "cookie", "secret"}),
+		ScrubMode:       envChoice("SCRUB_MODE", "mask", "mask", "remove"),
+
+		KeepAttributes: envKeepAttributes("KEEP_ATTRIBUTES"),
+
+		GlobalSpanAttributes: envStringMap("GLOBAL_SPAN_ATTRIBUTES"),
+
+		FeatureFlags: envFeatureFlags("FEATURE_FLAGS"),
+
+		TraceFingerprint: envBool("TRACE_FINGERPRINT", false),
+
+		DownstreamURL:           envString("DOWNSTREAM_URL", ""),
+		DownstreamPeerService:   envString("DOWNSTREAM_PEER_SERVICE", ""),
+		DownstreamMaxRetries:    envInt("DOWNSTREAM_MAX_RETRIES", 2),
+		DownstreamRetryBackoff:  envDuration("DOWNSTREAM_RETRY_BACKOFF", 100*time.Millisecond),
+		DownstreamMaxRetryAfter: envDuration("DOWNSTREAM_MAX_RETRY_AFTER", 2*time.Second),
+		DownstreamConnectDelay:  envDuration("DOWNSTREAM_CONNECT_DELAY", 0),
+
+		RetryBudgetRate:  envFloat("RETRY_BUDGET_RATE", 0),
+		RetryBudgetBurst: envInt("RETRY_BUDGET_BURST", 10),
+
+		UpstreamRate:    envFloat("UPSTREAM_RATE", 0),
+		UpstreamURL:     envString("UPSTREAM_URL", ""),
+		UpstreamBaggage: envString("UPSTREAM_BAGGAGE", ""),
+
+		Propagators:         envList("OTEL_PROPAGATORS", []string{"tracecontext", "baggage"}),
+		OutboundPropagators: envList("OUTBOUND_PROPAGATORS", envList("OTEL_PROPAGATORS", []string{"tracecontext", "baggage"})),
+		DebugHeaderEnabled:  envBool("DEBUG_HEADER_ENABLED", false),
+		CustomTraceHeader:   envString("CUSTOM_TRACE_HEADER", "X-Trace-Id"),
+		CustomTraceFormat:   envChoice("CUSTOM_TRACE_FORMAT", "hex", customTraceFormats...),
+
+		SpanJSONExport: envChoice("SPAN_JSON_EXPORT", "off", "off", "memory", "stdout"),
+
+		OTLPEndpoints: envEndpoints("OTLP_ENDPOINTS",
+			envEndpoints("OTEL_EXPORTER_OTLP_ENDPOINT", []string{"otel-collector:4317"})),
+
+		LogsExporter: envChoice("OTEL_LOGS_EXPORTER", "none", "none", "otlp"),
+
+		CollectorHealthInterval:   envDuration("COLLECTOR_HEALTH_INTERVAL", 0),
+		CollectorRequiredForReady: envBool("COLLECTOR_REQUIRED_FOR_READY", false),
+		CollectorHealthURL:        envString("COLLECTOR_HEALTH_URL", ""),
+		BackgroundWorkers:         envInt("BACKGROUND_WORKERS", 4),
+
+		SDKObservability: envBool("OTEL_GO_X_OBSERVABILITY", false),
+
+		GoroutineLeakWindow:    envDuration("GOROUTINE_LEAK_WINDOW", time.Minute),
+		GoroutineLeakMinGrowth: envInt("GOROUTINE_LEAK_MIN_GROWTH", 10),
+
+		Sampler:    envString("OTEL_TRACES_SAMPLER", "parentbased_always_on"),
+		SamplerArg: envString("OTEL_TRACES_SAMPLER_ARG", ""),
+
+		OTLPHeaders: envString("OTEL_EXPORTER_OTLP_HEADERS", ""),
+
+		ExportMaxConcurrency: envInt("EXPORT_MAX_CONCURRENCY", 0),
+
+		FailureRate: envFloat("FAILURE_RATE", 0.2),
+		WarnRate:    envFloat("WARN_RATE", 0),
+
+		RouteFailureRates: envFloatMap("ROUTE_FAILURE_RATES", map[string]float64{}),
+
+		EchoDelayPerKB: envDuration("ECHO_DELAY_PER_KB", time.Millisecond),
+
+		RangePayloadBytes: envInt("RANGE_PAYLOAD_BYTES", 64<<10),
+
+		IncidentDuration:    envDuration("INCIDENT_DURATION", 5*time.Minute),
+		IncidentFailureRate: envFloat("INCIDENT_FAILURE_RATE", 0.8),
+		IncidentLatency:     envDuration("INCIDENT_LATENCY", 500*time.Millisecond),
+		AnomalySchedule:     envAnomalySchedule("ANOMALY_SCHEDULE"),
+
+		RampRate:  envFloat("RAMP_RATE", 0),
+		RampStart: envFloat("RAMP_START", 0),
+
+		DegradeRamp:     envDuration("DEGRADE_RAMP", 5*time.Minute),
+		DegradeMaxRatio: envFloat("DEGRADE_MAX_RATIO", 0.9),
+
+		CacheHitRatio: envFloat("CACHE_HIT_RATIO", 0.5),
+
+		CacheEvictionInterval: envDuration("CACHE_EVICTION_INTERVAL", 0),
+
+		DBFailureRate:       envFloat("DB_FAILURE_RATE", 0),
+		DBPoolSize:          envInt("DB_POOL_SIZE", 0),
+		CascadeWindow:       envDuration("CASCADE_WINDOW", 0),
+		CascadeFailureBoost: envFloat("CASCADE_FAILURE_BOOST", 0.5),
+
+		AuthEnabled:     envBool("AUTH_ENABLED", false),
+		AuthLatency:     envDuration("AUTH_LATENCY", 20*time.Millisecond),
+		AuthFailureRate: envFloat("AUTH_FAILURE_RATE", 0.05),
+
+		ColdStartLatency: envDuration("COLD_START_LATENCY", 0),
+
+		GRPCDepEnabled:   envBool("GRPC_DEP_ENABLED", false),
+		GRPCDepErrorRate: envFloat("GRPC_DEP_ERROR_RATE", 0.1),
+		GRPCDepErrorCode: envGRPCCode("GRPC_DEP_ERROR_CODE", codes.Unavailable),
+
+		MQEnabled:     envBool("MQ_ENABLED", false),
+		MQProduceRate: envFloat("MQ_PRODUCE_RATE", 10),
+		MQProcessTime: envDuration("MQ_PROCESS_TIME", 80*time.Millisecond),
+		MQQueueSize:   envInt("MQ_QUEUE_SIZE", 1000),
+	}
+	c.OTLPTraceEndpoints = envEndpoints("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", c.OTLPEndpoints)
+	c.OTLPMetricEndpoints = envEndpoints("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", c.OTLPEndpoints)
+	c.OTLPLogEndpoints = envEndpoints("OTEL_EXPORTER_OTLP_LOGS_ENDPOINT", c.OTLPEndpoints)
+	if err := c.validate(); err != nil {
+		log.Fatalf("invalid configuration: %v", err)
+	}
+	return c
+}
+
+// validate catches values that parse fine but make no sense
+func (c Config) validate() error {
+	rates := map[string]float64{
+		"FAILURE_RATE":            c.FailureRate,
+		"WARN_RATE":               c.WarnRate,
+		"CACHE_HIT_RATIO":         c.CacheHitRatio,
+		"DB_FAILURE_RATE":         c.DBFailureRate,
+		"CASCADE_FAILURE_BOOST":   c.CascadeFailureBoost,
+		"INCIDENT_FAILURE_RATE":   c.IncidentFailureRate,
+		"DEGRADE_MAX_RATIO":       c.DegradeMaxRatio,
+		"GRPC_DEP_ERROR_RATE":     c.GRPCDepErrorRate,
+		"AUTH_FAILURE_RATE":       c.AuthFailureRate,
+		"ACCESS_LOG_SAMPLE_RATIO": c.AccessLogSampleRatio,
+	}
+	for route, v := range c.RouteFailureRates {
+		rates["ROUTE_FAILURE_RATES "+route] = v
+	}
+	for key, v := range rates {
+		if v < 0 || v > 1 {
+			return fmt.Errorf("%s must be between 0 and 1, got %v", key, v)
+		}
+	}
+	if !strings.HasPrefix(c.MetricsPath, "/") {
+		return fmt.Errorf("METRICS_PATH must start with /, got %q", c.MetricsPath)
+	}
+	if c.RangePayloadBytes <= 0 {
+		return fmt.Errorf("RANGE_PAYLOAD_BYTES must be positive, got %d", c.RangePayloadBytes)
+	}
+	if c.MaxHeaderBytes <= 0 {
+		return fmt.Errorf("MAX_HEADER_BYTES must be positive, got %d", c.MaxHeaderBytes)
+	}
+	if c.MaxBodyBytes <= 0 {
+		return fmt.Errorf("MAX_BODY_BYTES must be positive, got %d", c.MaxBodyBytes)
+	}
+	// The producer ticks every 1s/MQ_PRODUCE_RATE, which can't be under 1ns
+	if c.MQEnabled && !(c.MQProduceRate > 0 && c.MQProduceRate <= float64(time.Second)) {
+		return fmt.Errorf("MQ_PRODUCE_RATE must be between 0 (exclusive) and %g, got %v", float64(time.Second), c.MQProduceRate)
+	}
+	if c.MQEnabled && c.MQQueueSize <= 0 {
+		return fmt.Errorf("MQ_QUEUE_SIZE must be positive, got %d", c.MQQueueSize)
+	}
+	if c.CacheEvictionInterval < 0 {
+		return fmt.Errorf("CACHE_EVICTION_INTERVAL must not be negative, got %s", c.CacheEvictionInterval)
+	}
+	if c.SpanPercentileSamples < 0 {
+		return fmt.Errorf("SPAN_PERCENTILE_SAMPLES must not be negative, got %d", c.SpanPercentileSamples)
+	}
+	if c.LogAsyncBuffer < 0 {
+		return fmt.Errorf("LOG_ASYNC_BUFFER must not be negative, got %d", c.LogAsyncBuffer)
+	}
+	if c.LogAsyncBuffer > 0 && c.LogFlushInterval <= 0 {
+		return fmt.Errorf("LOG_FLUSH_INTERVAL must be positive when LOG_ASYNC_BUFFER is set, got %s", c.LogFlushInterval)
+	}
+	if c.ShutdownFlushTimeout <= 0 {
+		return fmt.Errorf("SHUTDOWN_FLUSH_TIMEOUT must be positive, got %s", c.ShutdownFlushTimeout)
+	}
+	if c.MaxConcurrentRequests < 0 {
+		return fmt.Errorf("MAX_CONCURRENT_REQUESTS must not be negative, got %d", c.MaxConcurrentRequests)
+	}
+	if c.SLOTarget <= 0 || c.SLOTarget >= 1 {
+		return fmt.Errorf("SLO_TARGET must be between 0 and 1 (exclusive), got %v", c.SLOTarget)
+	}
+	if c.SLOBurnWindow <= 0 {
+		return fmt.Errorf("SLO_BURN_WINDOW must be positive, got %s", c.SLOBurnWindow)
+	}
+	if c.SLOBurnWindow/sloSlots <= 0 {
+		return fmt.Errorf("SLO_BURN_WINDOW must be at least %s to split into %d slots, got %s",
+			time.Duration(sloSlots), sloSlots, c.SLOBurnWindow)
+	}
+	if c.ApdexTarget > 0 && c.ApdexWindow <= 0 {
+		return fmt.Errorf("APDEX_WINDOW must be positive when APDEX_T is set, got %s", c.ApdexWindow)
+	}
+	if c.DBPoolSize < 0 {
+		return fmt.Errorf("DB_POOL_SIZE must not be negative, got %d", c.DBPoolSize)
+	}
+	if c.SlowRequestThreshold < 0 {
+		return fmt.Errorf("SLOW_REQUEST_MS must not be negative, got %d", c.SlowRequestThreshold.Milliseconds())
+	}
+	if c.ExportMaxConcurrency < 0 {
+		return fmt.Errorf("EXPORT_MAX_CONCURRENCY must not be negative, got %d", c.ExportMaxConcurrency)
+	}
+	if c.BackgroundWorkers < 1 {
+		return fmt.Errorf("BACKGROUND_WORKERS must be positive, got %d", c.BackgroundWorkers)
+	}
+	if c.RetryBudgetRate < 0 {
+		return fmt.Errorf("RETRY_BUDGET_RATE must not be negative, got %g", c.RetryBudgetRate)
+	}
+	// The upstream ticks every 1s/UPSTREAM_RATE, which can't be under 1ns
+	if c.UpstreamRate < 0 || c.UpstreamRate > float64(time.Second) {
+		return fmt.Errorf("UPSTREAM_RATE must be between 0 and %g, got %v", float64(time.Second), c.UpstreamRate)
+	}
+	if c.RetryBudgetBurst < 1 {
+		return fmt.Errorf("RETRY_BUDGET_BURST must be at least 1, got %d", c.RetryBudgetBurst)
+	}
+	// The goroutine watch samples goroutineSamples times per window; 0 is off
+	if c.GoroutineLeakWindow < 0 || c.GoroutineLeakWindow > 0 && c.GoroutineLeakWindow/goroutineSamples <= 0 {
+		return fmt.Errorf("GOROUTINE_LEAK_WINDOW must be 0 or at least %s, got %s",
+			time.Duration(goroutineSamples), c.GoroutineLeakWindow)
+	}
+	if c.DownstreamMaxRetries < 0 {
+		return fmt.Errorf("DOWNSTREAM_MAX_RETRIES must not be negative, got %d", c.DownstreamMaxRetries)
+	}
+	if c.IncidentDuration <= 0 {
+		return fmt.Errorf("INCIDENT_DURATION must be positive, got %s", c.IncidentDuration)
+	}
+	for _, name := range c.DropRequestLabels {
+		if !slices.Contains(reqLabelNamesAll, name) {
+			return fmt.Errorf("DROP_REQUEST_LABELS: unknown label %q (want method or status)", name)
+		}
+	}
+	for _, class := range c.GoRuntimeMetrics {
+		if _, ok := goRuntimeMetricClasses[class]; !ok {
+			return fmt.Errorf("GO_RUNTIME_METRICS: unknown class %q (want gc, memory, sched or all)", class)
+		}
+	}
+	if _, ok := c.RegionLatencies[c.Region]; c.Region != "" && !ok {
+		return fmt.Errorf("REGION %q has no entry in REGION_LATENCIES", c.Region)
+	}
+	if c.DegradeRamp <= 0 {
+		return fmt.Errorf("DEGRADE_RAMP must be positive, got %s", c.DegradeRamp)
+	}
+	if c.CollectorRequiredForReady && c.CollectorHealthInterval <= 0 {
+		return errors.New("COLLECTOR_REQUIRED_FOR_READY needs COLLECTOR_HEALTH_INTERVAL to be set")
+	}
+	if c.CollectorHealthURL != "" && c.CollectorHealthInterval <= 0 {
+		return errors.New("COLLECTOR_HEALTH_URL needs COLLECTOR_HEALTH_INTERVAL to be set")
+	}
+	// The leak watch ticks every half MAX_SPAN_DURATION
+	if c.MaxSpanDuration < 0 || c.MaxSpanDuration > 0 && c.MaxSpanDuration/2 <= 0 {
+		return fmt.Errorf("MAX_SPAN_DURATION must be 0 or at least 2ns, got %s", c.MaxSpanDuration)
+	}
+	if len(c.OTLPEndpoints) == 0 {
+		return errors.New("at least one OTLP endpoint is required")
+	}
+	// Every exporter is plaintext, so https:// (or any other scheme left
+	// after envEndpoints) would only fail later as an unresolvable host
+	for _, list := range [][]string{c.OTLPEndpoints, c.OTLPTraceEndpoints, c.OTLPMetricEndpoints, c.OTLPLogEndpoints} {
+		for _, e := range list {
+			if strings.Contains(e, "://") {
+				return fmt.Errorf("OTLP endpoint %q: TLS isn't supported, use host:port or http://host:port", e)
+			}
+		}
+	}
+	return nil
+}
+
+// readConfigFile loads a YAML (or JSON, which YAML accepts) file of
+// settings. Keys are the environment variable names, case-insensitive, so
+// failure_rate: 0.3 is the same as FAILURE_RATE=0.3. Lists become
+// comma-separated values and maps key=value pairs, matching the env syntax.
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	settings := make(map[string]string, len(raw))
+	for key, v := range raw {
+		settings[strings.ToUpper(key)] = settingString(v)
+	}
+	return settings, nil
+}
+
+func settingString(v any) string {
+	switch v := v.(type) {
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = settingString(item)
+		}
+		return strings.Join(items, ",")
+	case map[string]any:
+		items := make([]string, 0, len(v))
+		for key, item := range v {
+			items = append(items, key+"="+settingString(item))
+		}
+		sort.Strings(items)
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// LogValue summarizes the effective configuration for the startup log, with
+// anything that may hold credentials redacted
+func (c Config) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("deployment_id", c.DeploymentID),
+		slog.String("region", c.Region),
+		slog.String("listen_addr", c.ListenAddr),
+		slog.String("listen_network", c.ListenNetwork),
+		slog.Bool("reuse_port", c.ReusePort),
+		slog.Bool("h2c", c.H2C),
+		slog.Int("range_payload_bytes", c.RangePayloadBytes),
+		slog.String("grpc_listen_addr", c.GRPCListenAddr),
+		slog.Int("max_header_bytes", c.MaxHeaderBytes),
+		slog.Int64("max_body_bytes", c.MaxBodyBytes),
+		slog.String("request_timeout", c.RequestTimeout.String()),
+		slog.Any("route_timeouts", durationStrings(c.RouteTimeouts)),
+		slog.Int("max_concurrent_requests", c.MaxConcurrentRequests),
+		slog.String("lameduck_duration", c.LameDuckDuration.String()),
+		slog.String("shutdown_timeout", c.ShutdownTimeout.String()),
+		slog.String("shutdown_flush_timeout", c.ShutdownFlushTimeout.String()),
+		slog.Any("otlp_endpoints", c.OTLPEndpoints),
+		slog.Any("otlp_trace_endpoints", c.OTLPTraceEndpoints),
+		slog.Any("otlp_metric_endpoints", c.OTLPMetricEndpoints),
+		slog.Any("otlp_log_endpoints", c.OTLPLogEndpoints),
+		slog.String("logs_exporter", c.LogsExporter),
+		slog.String("otlp_protocol", "grpc"),
+		slog.Int("export_max_concurrency", c.ExportMaxConcurrency),
+		slog.Bool("collector_required_for_ready", c.CollectorRequiredForReady),
+		slog.String("collector_health_url", redactURL(c.CollectorHealthURL)),
+		slog.Int("background_workers", c.BackgroundWorkers),
+		slog.Any("otlp_headers", redactHeaders(c.OTLPHeaders)),
+		slog.String("sampler", c.Sampler),
+		slog.String("sampler_arg", c.SamplerArg),
+		slog.Any("propagators", c.Propagators),
+		slog.Any("outbound_propagators", c.OutboundPropagators),
+		slog.Bool("debug_header_enabled", c.DebugHeaderEnabled),
+		slog.String("custom_trace_header", c.CustomTraceHeader),
+		slog.String("custom_trace_format", c.CustomTraceFormat),
+		slog.Float64("failure_rate", c.FailureRate),
+		slog.Any("route_failure_rates", c.RouteFailureRates),
+		slog.Float64("warn_rate", c.WarnRate),
+		slog.Float64("cache_hit_ratio", c.CacheHitRatio),
+		slog.String("cache_eviction_interval", c.CacheEvictionInterval.String()),
+		slog.Float64("db_failure_rate", c.DBFailureRate),
+		slog.Int("db_pool_size", c.DBPoolSize),
+		slog.String("cascade_window", c.CascadeWindow.String()),
+		slog.Float64("cascade_failure_boost", c.CascadeFailureBoost),
+		slog.String("span_detail", c.SpanDetail.String()),
+		slog.String("max_span_duration", c.MaxSpanDuration.String()),
+		slog.Int("span_percentile_samples", c.SpanPercentileSamples),
+		slog.Bool("trace_background_tasks", c.TraceBackgroundTasks),
+		slog.String("clock_skew", c.ClockSkew.String()),
+		slog.Any("scrub_attributes", c.ScrubAttributes),
+		slog.String("scrub_mode", c.ScrubMode),
+		slog.Any("keep_attributes", c.KeepAttributes),
+		slog.Any("global_span_attributes", c.GlobalSpanAttributes),
+		slog.Any("feature_flags", c.FeatureFlags),
+		slog.String("metrics_path", c.MetricsPath),
+		slog.Bool("enable_exemplars", c.EnableExemplars),
+		slog.String("metrics_dump_file", c.MetricsDumpFile),
+		slog.Bool("test_deterministic", c.TestDeterministic),
+		slog.String("exemplar_min_age", c.ExemplarMinAge.String()),
+		slog.String("exemplar_mode", c.ExemplarMode),
+		slog.Bool("exemplar_log_id", c.ExemplarLogID),
+		slog.Bool("native_histograms", c.NativeHistograms),
+		slog.Bool("utf8_metric_names", c.UTF8MetricNames),
+		slog.Bool("metrics_warmup", c.MetricsWarmup),
+		slog.Any("go_runtime_metrics", c.GoRuntimeMetrics),
+		slog.Any("drop_request_labels", c.DropRequestLabels),
+		slog.Bool("instrumentation_overhead", c.InstrumentationOverhead),
+		slog.Bool("config_metrics", c.ConfigMetrics),
+		slog.Float64("ramp_rate", c.RampRate),
+		slog.Float64("ramp_start", c.RampStart),
+		slog.Float64("slo_target", c.SLOTarget),
+		slog.String("slo_burn_window", c.SLOBurnWindow.String()),
+		slog.String("apdex_t", c.ApdexTarget.String()),
+		slog.String("apdex_window", c.ApdexWindow.String()),
+		slog.Int("log_async_buffer", c.LogAsyncBuffer),
+		slog.String("log_flush_interval", c.LogFlushInterval.String()),
+		slog.Bool("access_log", c.AccessLog),
+		slog.Bool("admin_audit_log", c.AdminAuditLog),
+		slog.String("slow_request_threshold", c.SlowRequestThreshold.String()),
+		slog.Bool("panic_trace_in_body", c.PanicTraceInBody),
+		slog.String("path_normalization", c.PathNormalization),
+		slog.Float64("access_log_sample_ratio", c.AccessLogSampleRatio),
+		slog.Bool("client_info_enabled", c.ClientInfoEnabled),
+		slog.Any("trusted_proxies", c.TrustedProxies),
+		slog.Any("capture_request_headers", c.CaptureRequestHeaders),
+		slog.Any("capture_response_headers", c.CaptureResponseHeaders),
+		slog.Bool("trace_fingerprint", c.TraceFingerprint),
+		slog.Bool("request_summary_enabled", c.RequestSummaryEnabled),
+		slog.Bool("sdk_observability", c.SDKObservability),
+		slog.String("span_json_export", c.SpanJSONExport),
+		slog.String("downstream_url", redactURL(c.DownstreamURL)),
+		slog.String("downstream_peer_service", c.DownstreamPeerService),
+		slog.String("downstream_connect_delay", c.DownstreamConnectDelay.String()),
+		slog.Float64("retry_budget_rate", c.RetryBudgetRate),
+		slog.Int("retry_budget_burst", c.RetryBudgetBurst),
+		slog.Float64("upstream_rate", c.UpstreamRate),
+		slog.String("upstream_url", redactURL(c.UpstreamURL)),
+		slog.String("upstream_baggage", c.UpstreamBaggage),
+		slog.Bool("auth_enabled", c.AuthEnabled),
+		slog.String("auth_latency", c.AuthLatency.String()),
+		slog.Float64("auth_failure_rate", c.AuthFailureRate),
+		slog.String("cold_start_latency", c.ColdStartLatency.String()),
+		slog.Bool("grpc_dep_enabled", c.GRPCDepEnabled),
+		slog.Bool("mq_enabled", c.MQEnabled),
+		slog.Float64("mq_produce_rate", c.MQProduceRate),
+		slog.String("mq_process_time", c.MQProcessTime.String()),
+		slog.Int("mq_queue_size", c.MQQueueSize),
+	)
+}
+
+func durationStrings(m map[string]time.Duration) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, d := range m {
+		out[k] = d.String()
+	}
+	return out
+}
+
+// redactHeaders keeps header names but hides their values
+func redactHeaders(headers string) []string {
+	var out []string
+	for _, kv := range strings.Split(headers, ",") {
+		if name, _, ok := strings.Cut(kv, "="); ok {
+			out = append(out, strings.TrimSpace(name)+"=REDACTED")
+		}
+	}
+	return out
+}
+
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "REDACTED"
+	}
+	return u.Redacted()
+}
+
+func getenv(key string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fileSettings[key]
+}
+
+func envString(key, def string) string {
+	if v := getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// envChoice is envString restricted to a fixed set of values
+func envChoice(key, def string, allowed ...string) string {
+	v := envString(key, def)
+	if !slices.Contains(allowed, v) {
+		log.Fatalf("invalid %s %q: must be one of %s", key, v, strings.Join(allowed, ", "))
+	}
+	return v
+}
+
+func envInt(key string, def int) int {
+	v := getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Fatalf("invalid %s %q: %v", key, v, err)
+	}
+	return n
+}
+
+func envFloat(key string, def float64) float64 {
+	v := getenv(key)
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		log.Fatalf("invalid %s %q: %v", key, v, err)
+	}
+	return f
+}
+
+func envBool(key string, def bool) bool {
+	v := getenv(key)
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Fatalf("invalid %s %q: %v", key, v, err)
+	}
+	return b
+}
+
+func envGRPCCode(key string, def codes.Code) codes.Code {
+	v := getenv(key)
+	if v == "" {
+		return def
+	}
+	c, err := parseGRPCCode(v)
+	if err != nil {
+		log.Fatalf("invalid %s %q: %v", key, v, err)
+	}
+	return c
+}
+
+func envSpanDetail(key string, def spanDetail) spanDetail {
+	v := getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := parseSpanDetail(v)
+	if err != nil {
+		log.Fatalf("invalid %s %q: %v", key, v, err)
+	}
+	return d
+}
+
+// envDurationMap parses name=duration pairs, e.g. per-route timeouts written
+// as "work=2s,healthz=200ms"
+func envDurationMap(key string, def map[string]time.Duration) map[string]time.Duration {
+	items := envList(key, nil)
+	if items == nil {
+		return def
+	}
+	out := map[string]time.Duration{}
+	for _, item := range items {
+		name, raw, ok := strings.Cut(item, "=")
+		d, err := time.ParseDuration(raw)
+		if !ok || err != nil {
+			log.Fatalf("invalid %s entry %q: want name=duration", key, item)
+		}
+		out[strings.TrimSpace(name)] = d
+	}
+	return out
+}
+
+func envFloatMap(key string, def map[string]float64) map[string]float64 {
+	items := envList(key, nil)
+	if items == nil {
+		return def
+	}
+	out := map[string]float64{}
+	for _, item := range items {
+		name, raw, ok := strings.Cut(item, "=")
+		v, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if !ok || err != nil {
+			log.Fatalf("invalid %s entry %q: want name=number", key, item)
+		}
+		out[strings.TrimSpace(name)] = v
+	}
+	return out
+}
+
+// envKeepAttributes parses key=value entries; a key may be listed more than
+// once to accept several values, e.g. tenant.id=acme,tenant.id=globex
+func envKeepAttributes(key string) map[string][]string {
+	out := map[string][]string{}
+	for _, item := range envList(key, nil) {
+		name, value, ok := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			log.Fatalf("invalid %s entry %q: want attribute=value", key, item)
+		}
+		out[name] = append(out[name], strings.TrimSpace(value))
+	}
+	return out
+}
+
+// envStringMap parses key=value entries; a repeated key keeps its last value
+func envStringMap(key string) map[string]string {
+	out := map[string]string{}
+	for _, item := range envList(key, nil) {
+		name, value, ok := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			log.Fatalf("invalid %s entry %q: want key=value", key, item)
+		}
+		out[name] = strings.TrimSpace(value)
+	}
+	return out
+}
+
+// envFeatureFlags parses flag=variant:weight entries, one per variant, e.g.
+// work_fast_path=on:20,work_fast_path=off:80
+func envFeatureFlags(key string) map[string][]flagVariant {
+	out := map[string][]flagVariant{}
+	for _, item := range envList(key, nil) {
+		flag, rest, ok1 := strings.Cut(item, "=")
+		variant, rawWeight, ok2 := strings.Cut(rest, ":")
+		weight, err := strconv.ParseFloat(strings.TrimSpace(rawWeight), 64)
+		flag, variant = strings.TrimSpace(flag), strings.TrimSpace(variant)
+		if !ok1 || !ok2 || err != nil || flag == "" || variant == "" || weight <= 0 {
+			log.Fatalf("invalid %s entry %q: want flag=variant:weight with a positive weight", key, item)
+		}
+		out[flag] = append(out[flag], flagVariant{name: variant, weight: weight})
+	}
+	return out
+}
+
+// envAnomalySchedule parses period/duration:kind entries, e.g. 15m/1m:errors
+func envAnomalySchedule(key string) []anomalyWindow {
+	var out []anomalyWindow
+	for _, item := range envList(key, nil) {
+		timing, kind, _ := strings.Cut(item, ":")
+		rawEvery, rawDuration, ok := strings.Cut(timing, "/")
+		every, err1 := time.ParseDuration(rawEvery)
+		duration, err2 := time.ParseDuration(rawDuration)
+		if !ok || err1 != nil || err2 != nil || !slices.Contains(anomalyKinds, kind) {
+			log.Fatalf("invalid %s entry %q: want period/duration:kind with kind one of %v", key, item, anomalyKinds)
+		}
+		if duration <= 0 || duration >= every {
+			log.Fatalf("invalid %s entry %q: duration must be positive and shorter than the period", key, item)
+		}
+		out = append(out, anomalyWindow{every: every, duration: duration, kind: kind})
+	}
+	return out
+}
+
+// envEndpoints is envList for OTLP/gRPC endpoints. The exporters want
+// host:port, so an http:// scheme, as in the standard OTEL_EXPORTER_OTLP_*
+// examples, is dropped. Other schemes are kept for validate to reject.
+func envEndpoints(key string, def []string) []string {
+	items := envList(key, nil)
+	if items == nil {
+		return def
+	}
+	for i, item := range items {
+		items[i] = strings.TrimSuffix(strings.TrimPrefix(item, "http://"), "/")
+	}
+	return items
+}
+
+// envPatterns is envList lowercased, with "none" meaning no patterns at all
+func envPatterns(key string, def []string) []string {
+	items := envList(key, def)
+	if len(items) == 1 && items[0] == "none" {
+		return nil
+	}
+	for i, item := range items {
+		items[i] = strings.ToLower(item)
+	}
+	return items
+}
+
+// envPrefixes parses a list of CIDRs; bare addresses are taken as a single host
+func envPrefixes(key string) []netip.Prefix {
+	var out []netip.Prefix
+	for _, item := range envList(key, nil) {
+		p, err := netip.ParsePrefix(item)
+		if err != nil {
+			addr, aerr := netip.ParseAddr(item)
+			if aerr != nil {
+				log.Fatalf("invalid %s entry %q: want a CIDR or an IP address", key, item)
+			}
+			p = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// envObjectives parses summary objectives written as "0.5:0.05,0.99:0.001"
+func envObjectives(key string, def map[float64]float64) map[float64]float64 {
+	items := envList(key, nil)
+	if items == nil {
+		return def
+	}
+	out := make(map[float64]float64, len(items))
+	for _, item := range items {
+		q, e, ok := strings.Cut(item, ":")
+		quantile, err1 := strconv.ParseFloat(q, 64)
+		epsilon, err2 := strconv.ParseFloat(e, 64)
+		if !ok || err1 != nil || err2 != nil || quantile <= 0 || quantile >= 1 {
+			log.Fatalf("invalid %s entry %q: want quantile:error with 0 < quantile < 1", key, item)
+		}
+		out[quantile] = epsilon
+	}
+	return out
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	v := getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Fatalf("invalid %s %q: %v", key, v, err)
+	}
+	return d
+}
+
+// envList parses a comma-separated list, ignoring empty entries
+func envList(key string, def []string) []string {
+	v := getenv(key)
+	if v == "" {
+		return def
+	}
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
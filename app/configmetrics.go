@@ -0,0 +1,31 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// configGauges exposes the config values most likely to drift between
+// instances as config_* gauges. Every pod of a deployment should report the
+// same values, so a dashboard of count by value shows misconfigured ones.
+func configGauges() []prometheus.Collector {
+	values := []struct {
+		name, help string
+		value      func() float64
+	}{
+		{"config_failure_rate", "Configured FAILURE_RATE", func() float64 { return cfg.FailureRate }},
+		{"config_warn_rate", "Configured WARN_RATE", func() float64 { return cfg.WarnRate }},
+		{"config_sampling_ratio", "Fraction of root spans the configured OTEL_TRACES_SAMPLER keeps", samplerRatio},
+		{"config_max_concurrent_requests", "Configured MAX_CONCURRENT_REQUESTS (0 = unlimited)", func() float64 { return float64(cfg.MaxConcurrentRequests) }},
+		{"config_request_timeout_seconds", "Configured REQUEST_TIMEOUT", func() float64 { return cfg.RequestTimeout.Seconds() }},
+		{"config_cache_hit_ratio", "Configured CACHE_HIT_RATIO", func() float64 { return cfg.CacheHitRatio }},
+		{"config_db_failure_rate", "Configured DB_FAILURE_RATE", func() float64 { return cfg.DBFailureRate }},
+	}
+	var gauges []prometheus.Collector
+	for _, v := range values {
+		gauges = append(gauges, prometheus.NewGaugeFunc(
+			prometheus.GaugeOpts{Name: v.name, Help: v.help},
+			v.value,
+		))
+	}
+	return gauges
+}
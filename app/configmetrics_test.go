@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestConfigGauges(t *testing.T) {
+	app := startApp(t,
+		"CONFIG_METRICS=true",
+		"FAILURE_RATE=0.25",
+		"OTEL_TRACES_SAMPLER=parentbased_traceidratio",
+		"OTEL_TRACES_SAMPLER_ARG=0.4",
+		"MAX_CONCURRENT_REQUESTS=7",
+		"REQUEST_TIMEOUT=3s",
+	)
+	families := app.scrape()
+	for name, want := range map[string]float64{
+		"config_failure_rate":            0.25,
+		"config_sampling_ratio":          0.4,
+		"config_max_concurrent_requests": 7,
+		"config_request_timeout_seconds": 3,
+	} {
+		if got, ok := seriesValue(families, name); !ok || got != want {
+			t.Errorf("%s = %v (present %v), want %v", name, got, ok, want)
+		}
+	}
+}
+
+func TestConfigGaugesDisabled(t *testing.T) {
+	app := startApp(t)
+	if _, ok := seriesValue(app.scrape(), "config_failure_rate"); ok {
+		t.Error("config_failure_rate exported without CONFIG_METRICS")
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDownstreamConnectDelay(t *testing.T) {
+	stub := startStub(t, http.StatusOK)
+	app := startApp(t, "DOWNSTREAM_URL="+stub.URL, "DOWNSTREAM_CONNECT_DELAY=300ms")
+	app.get("/work")
+	app.get("/work")
+
+	attempts := app.spansNamed("GET", 2)
+	at := map[string]time.Time{}
+	for _, e := range attempts[0].Events {
+		at[e.Name] = e.Time
+	}
+	if at["connect_start"].IsZero() || at["connect_done"].IsZero() || at["first_byte"].IsZero() {
+		t.Fatalf("first call events %+v lack connect_start, connect_done or first_byte", attempts[0].Events)
+	}
+	if d := at["connect_done"].Sub(at["connect_start"]); d < 300*time.Millisecond {
+		t.Errorf("connect took %s, want at least the 300ms delay", d)
+	}
+	// The delay belongs to the connect, not to the response
+	if d := at["first_byte"].Sub(at["connect_done"]); d > 200*time.Millisecond {
+		t.Errorf("first byte came %s after the connect, want the delay kept out of it", d)
+	}
+
+	// A reused connection doesn't connect, so pays nothing
+	for _, e := range attempts[1].Events {
+		if e.Name == "connect_start" {
+			t.Errorf("second call connected again: %+v", attempts[1].Events)
+		}
+	}
+	if d := attempts[1].EndTime.Sub(attempts[1].StartTime); d > 200*time.Millisecond {
+		t.Errorf("second call took %s, want it free of the connect delay", d)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Upper bound on self-generated requests per /admin/consistency run
+const maxConsistencyBurst = 50
+
+// consistencyWatch records, per trace ID of a running self-test, whether a
+// correlated log record and a server span were seen. Only one run at a time
+// watches; everything else pays a single nil check.
+var consistencyWatch struct {
+	sync.Mutex
+	run      sync.Mutex
+	logs     map[trace.TraceID]bool
+	spans    map[trace.TraceID]bool
+	watching bool
+}
+
+// consistencyProbeKey marks the self-test's own /work requests, which skip
+// authentication and load shedding: a 401 or 503 there never reaches the
+// handler and would read as drift between the signals.
+type consistencyProbeKey struct{}
+
+func isConsistencyProbe(r *http.Request) bool {
+	return r.Context().Value(consistencyProbeKey{}) != nil
+}
+
+func watchTrace(tid trace.TraceID) {
+	consistencyWatch.Lock()
+	consistencyWatch.logs[tid] = false
+	consistencyWatch.spans[tid] = false
+	consistencyWatch.Unlock()
+}
+
+func sawSignal(seen map[trace.TraceID]bool, tid trace.TraceID) {
+	consistencyWatch.Lock()
+	defer consistencyWatch.Unlock()
+	if _, ok := seen[tid]; ok && consistencyWatch.watching {
+		seen[tid] = true
+	}
+}
+
+// correlationHandler notes log records carrying a watched trace_id
+type correlationHandler struct {
+	slog.Handler
+	traceID trace.TraceID
+}
+
+func (h correlationHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	tid := h.traceID
+	for _, a := range attrs {
+		if a.Key == "trace_id" {
+			tid, _ = trace.TraceIDFromHex(a.Value.String())
+		}
+	}
+	return correlationHandler{h.Handler.WithAttrs(attrs), tid}
+}
+
+func (h correlationHandler) WithGroup(name string) slog.Handler {
+	return correlationHandler{h.Handler.WithGroup(name), h.traceID}
+}
+
+func (h correlationHandler) Handle(ctx context.Context, r slog.Record) error {
+	if h.traceID.IsValid() {
+		sawSignal(consistencyWatch.logs, h.traceID)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// consistencySpans notes ended server spans of watched traces
+type consistencySpans struct{}
+
+func (consistencySpans) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+func (consistencySpans) OnEnd(s sdktrace.ReadOnlySpan) {
+	if s.SpanKind() == trace.SpanKindServer {
+		sawSignal(consistencyWatch.spans, s.SpanContext().TraceID())
+	}
+}
+func (consistencySpans) Shutdown(context.Context) error   { return nil }
+func (consistencySpans) ForceFlush(context.Context) error { return nil }
+
+type consistencyResult struct {
+	Pass               bool   `json:"pass"`
+	Reason             string `json:"reason,omitempty"`
+	Requests           int    `json:"requests"`
+	Logs               int    `json:"logs"`
+	MetricObservations uint64 `json:"metric_observations"`
+	Spans              int    `json:"spans"`
+}
+
+// consistencyHandler sends a burst of requests to /work through the full
+// in-process handler stack and checks that each produced a correlated log
+// record, a histogram observation and a server span. The metric count is a
+// delta of the whole histogram, so concurrent traffic shows up as a mismatch.
+func consistencyHandler(w http.ResponseWriter, r *http.Request) {
+	n := 10
+	if raw := r.URL.Query().Get("n"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 1 || v > maxConsistencyBurst {
+			http.Error(w, fmt.Sprintf("n must be between 1 and %d", maxConsistencyBurst), http.StatusBadRequest)
+			return
+		}
+		n = v
+	}
+
+	consistencyWatch.run.Lock()
+	defer consistencyWatch.run.Unlock()
+	consistencyWatch.Lock()
+	consistencyWatch.logs = map[trace.TraceID]bool{}
+	consistencyWatch.spans = map[trace.TraceID]bool{}
+	consistencyWatch.watching = true
+	consistencyWatch.Unlock()
+	defer func() {
+		consistencyWatch.Lock()
+		consistencyWatch.watching = false
+		consistencyWatch.Unlock()
+	}()
+
+	before := histogramCount()
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		// Each request is its own trace, rooted in a client span here
+		ctx, span := otel.Tracer("app").Start(context.Background(), "consistency-probe",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithNewRoot(),
+		)
+		watchTrace(span.SpanContext().TraceID())
+		req := newLoopbackRequest("/work")
+		req = req.WithContext(context.WithValue(req.Context(), consistencyProbeKey{}, true))
+		outboundPropagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			http.DefaultServeMux.ServeHTTP(&loopbackResponse{}, req)
+			span.End()
+		}()
+	}
+	wg.Wait()
+
+	res := consistencyResult{Requests: n, MetricObservations: histogramCount() - before}
+	consistencyWatch.Lock()
+	for _, seen := range consistencyWatch.logs {
+		if seen {
+			res.Logs++
+		}
+	}
+	for _, seen := range consistencyWatch.spans {
+		if seen {
+			res.Spans++
+		}
+	}
+	consistencyWatch.Unlock()
+
+	switch {
+	case res.Logs != n:
+		res.Reason = "some requests produced no correlated log record"
+	case res.Spans != n:
+		res.Reason = "some requests produced no server span (sampled out?)"
+	case res.MetricObservations != uint64(n):
+		res.Reason = "histogram observations don't match the request count (concurrent traffic?)"
+	default:
+		res.Pass = true
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+func histogramCount() uint64 {
+	var total uint64
+	for _, m := range collectMetrics(reqDuration) {
+		total += m.GetHistogram().GetSampleCount()
+	}
+	return total
+}
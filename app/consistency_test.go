@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestConsistencyCountsAgree(t *testing.T) {
+	app := startApp(t)
+	resp, body := app.get("/admin/consistency?n=15")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d: %s", resp.StatusCode, body)
+	}
+	var res consistencyResult
+	if err := json.Unmarshal([]byte(body), &res); err != nil {
+		t.Fatalf("decoding %q: %v", body, err)
+	}
+	if !res.Pass {
+		t.Errorf("self-test failed: %+v", res)
+	}
+	if res.Requests != 15 || res.Logs != 15 || res.Spans != 15 || res.MetricObservations != 15 {
+		t.Errorf("counts = %+v, want 15 of each", res)
+	}
+
+	if resp, _ := app.get("/admin/consistency?n=0"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("n=0 gave status %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestConsistencyProbesSkipAuthAndShedding(t *testing.T) {
+	// Every request would get a 401 or, past the first, a 503 on its way in
+	app := startApp(t, "AUTH_ENABLED=true", "AUTH_FAILURE_RATE=1", "MAX_CONCURRENT_REQUESTS=1")
+	resp, body := app.get("/admin/consistency?n=15")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d: %s", resp.StatusCode, body)
+	}
+	var res consistencyResult
+	if err := json.Unmarshal([]byte(body), &res); err != nil {
+		t.Fatalf("decoding %q: %v", body, err)
+	}
+	if !res.Pass {
+		t.Errorf("self-test failed: %+v", res)
+	}
+	if v := app.metricValue("auth_failures_total"); v != 0 {
+		t.Errorf("auth_failures_total = %v, want 0", v)
+	}
+	if v := app.metricValue("http_concurrency_rejections_total"); v != 0 {
+		t.Errorf("http_concurrency_rejections_total = %v, want 0", v)
+	}
+}
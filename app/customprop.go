@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	crand "crypto/rand"
+	"strings"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// customPropagator bridges systems that carry a trace ID in a header of
+// their own, selected with OTEL_PROPAGATORS=custom. Formats:
+//
+//	hex       32 hex digits: the trace ID
+//	uuid      the trace ID as a UUID (8-4-4-4-12)
+//	hex-span  trace ID and span ID in hex, joined by a dash
+//
+// Formats without a span ID get a random one, standing in for the upstream
+// caller's span, which won't exist in our backend.
+type customPropagator struct {
+	header string
+	format string
+}
+
+var customTraceFormats = []string{"hex", "uuid", "hex-span"}
+
+func (p customPropagator) Fields() []string { return []string{p.header} }
+
+func (p customPropagator) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	tid := sc.TraceID().String()
+	switch p.format {
+	case "uuid":
+		carrier.Set(p.header, tid[0:8]+"-"+tid[8:12]+"-"+tid[12:16]+"-"+tid[16:20]+"-"+tid[20:])
+	case "hex-span":
+		carrier.Set(p.header, tid+"-"+sc.SpanID().String())
+	default:
+		carrier.Set(p.header, tid)
+	}
+}
+
+func (p customPropagator) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	v := strings.ToLower(strings.TrimSpace(carrier.Get(p.header)))
+	if v == "" {
+		return ctx
+	}
+
+	var rawTrace, rawSpan string
+	switch p.format {
+	case "uuid":
+		rawTrace = strings.ReplaceAll(v, "-", "")
+	case "hex-span":
+		rawTrace, rawSpan, _ = strings.Cut(v, "-")
+	default:
+		rawTrace = v
+	}
+	tid, err := trace.TraceIDFromHex(rawTrace)
+	if err != nil {
+		return ctx
+	}
+	var sid trace.SpanID
+	if rawSpan != "" {
+		if sid, err = trace.SpanIDFromHex(rawSpan); err != nil {
+			return ctx
+		}
+	} else {
+		crand.Read(sid[:])
+	}
+
+	return trace.ContextWithRemoteSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    tid,
+		SpanID:     sid,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	}))
+}
@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestCustomHeaderContinuesTrace(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	for _, tc := range []struct {
+		format, header, parent string
+	}{
+		{"hex", traceID, ""},
+		{"uuid", "4bf92f35-77b3-4da6-a3ce-929d0e0e4736", ""},
+		{"hex-span", traceID + "-00f067aa0ba902b7", "00f067aa0ba902b7"},
+	} {
+		t.Run(tc.format, func(t *testing.T) {
+			app := startApp(t,
+				"OTEL_PROPAGATORS=custom",
+				"CUSTOM_TRACE_HEADER=X-Request-Trace",
+				"CUSTOM_TRACE_FORMAT="+tc.format,
+			)
+			app.get("/healthz", "X-Request-Trace", tc.header)
+
+			s := app.spansNamed("healthz", 1)[0]
+			if s.SpanContext.TraceID != traceID {
+				t.Errorf("trace ID = %s, want %s from the custom header", s.SpanContext.TraceID, traceID)
+			}
+			if tc.parent != "" && s.Parent.SpanID != tc.parent {
+				t.Errorf("parent span = %s, want %s", s.Parent.SpanID, tc.parent)
+			}
+		})
+	}
+}
+
+func TestCustomHeaderMalformedStartsNewTrace(t *testing.T) {
+	app := startApp(t, "OTEL_PROPAGATORS=custom", "CUSTOM_TRACE_HEADER=X-Request-Trace")
+	app.get("/healthz", "X-Request-Trace", "not-a-trace-id")
+
+	s := app.spansNamed("healthz", 1)[0]
+	if s.Parent.SpanID != "0000000000000000" {
+		t.Errorf("malformed header gave parent %s, want a root span", s.Parent.SpanID)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var dbPoolInUse = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "db_pool_in_use",
+		Help: "Simulated database connections currently checked out",
+	},
+)
+
+var dbPoolIdle = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "db_pool_idle",
+		Help: "Simulated database connections sitting idle in the pool",
+	},
+)
+
+var dbPoolWait = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Name:    "db_pool_wait_seconds",
+		Help:    "Time spent waiting for a simulated database connection",
+		Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	},
+)
+
+// Connections of the simulated pool; nil when DB_POOL_SIZE is 0
+var dbPool chan struct{}
+
+func initDBPool(size int) {
+	dbPool = make(chan struct{}, size)
+	dbPoolIdle.Set(float64(size))
+}
+
+// acquireDBConn checks out a connection, waiting in a pool_wait span when
+// all of them are busy. Call release once the query is done.
+func acquireDBConn(ctx context.Context) (release func(), err error) {
+	if dbPool == nil {
+		return func() {}, nil
+	}
+	start := time.Now()
+	select {
+	case dbPool <- struct{}{}:
+	default:
+		_, waitSpan := startPhase(ctx, "pool_wait",
+			trace.WithAttributes(attribute.Int("db.pool.size", cap(dbPool))),
+		)
+		select {
+		case dbPool <- struct{}{}:
+			waitSpan.End()
+		case <-ctx.Done():
+			waitSpan.End()
+			dbPoolWait.Observe(time.Since(start).Seconds())
+			return nil, ctx.Err()
+		}
+	}
+	dbPoolWait.Observe(time.Since(start).Seconds())
+	dbPoolInUse.Inc()
+	dbPoolIdle.Dec()
+	return func() {
+		<-dbPool
+		dbPoolInUse.Dec()
+		dbPoolIdle.Inc()
+	}, nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import "testing"
+
+func TestDBPoolSaturation(t *testing.T) {
+	app := startApp(t, "DB_POOL_SIZE=1", "CACHE_HIT_RATIO=0")
+	if v := app.metricValue("db_pool_idle"); v != 1 {
+		t.Errorf("db_pool_idle at start = %v, want 1", v)
+	}
+
+	// Eight queries of at least 100ms each can't all fit one connection in
+	// the 400ms the requests' simulated work spreads them over
+	const n = 8
+	done := make(chan []int)
+	go func() { done <- app.getConcurrently(n, "/work") }()
+	app.waitFor("the connection to be checked out", func() bool {
+		f := app.scrape()
+		inUse, _ := seriesValue(f, "db_pool_in_use")
+		idle, _ := seriesValue(f, "db_pool_idle")
+		if inUse > 1 {
+			t.Errorf("db_pool_in_use = %v, above the pool size of 1", inUse)
+		}
+		return inUse == 1 && idle == 0
+	})
+	<-done
+
+	fam := app.scrape()["db_pool_wait_seconds"]
+	h := fam.GetMetric()[0].GetHistogram()
+	if h.GetSampleCount() != n {
+		t.Errorf("db_pool_wait_seconds count = %d, want %d", h.GetSampleCount(), n)
+	}
+	if h.GetSampleSum() < 0.05 {
+		t.Errorf("db_pool_wait_seconds sum = %v, want real waiting on a saturated pool", h.GetSampleSum())
+	}
+	waits := app.spansNamed("pool_wait", 1)
+	if v := waits[0].attr("db.pool.size"); v != float64(1) {
+		t.Errorf("pool_wait db.pool.size = %v, want 1", v)
+	}
+	if v := app.metricValue("db_pool_in_use"); v != 0 {
+		t.Errorf("db_pool_in_use after the burst = %v, want 0", v)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	debugHeader     = "X-Debug"
+	debugBaggageKey = "debug"
+)
+
+// isDebug reports whether the request behind ctx asked for debug treatment,
+// either with X-Debug or through debug=1 baggage from an upstream service
+func isDebug(ctx context.Context) bool {
+	return cfg.DebugHeaderEnabled && baggage.FromContext(ctx).Member(debugBaggageKey).Value() == "1"
+}
+
+// debugPropagator turns an inbound X-Debug header into debug=1 baggage, so
+// the flag reaches the sampler at span start and follows the request to
+// every downstream service
+type debugPropagator struct{}
+
+func (debugPropagator) Fields() []string { return []string{debugHeader} }
+
+func (debugPropagator) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	if on, _ := strconv.ParseBool(carrier.Get(debugHeader)); !on {
+		return ctx
+	}
+	m, err := baggage.NewMember(debugBaggageKey, "1")
+	if err != nil {
+		return ctx
+	}
+	b, err := baggage.FromContext(ctx).SetMember(m)
+	if err != nil {
+		return ctx
+	}
+	return baggage.ContextWithBaggage(ctx, b)
+}
+
+// Inject sends the baggage even when OUTBOUND_PROPAGATORS leaves baggage
+// out, since downstream services only learn about the flag through it
+func (debugPropagator) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	if isDebug(ctx) {
+		propagation.Baggage{}.Inject(ctx, carrier)
+	}
+}
+
+// debugSampler samples every debug request regardless of the configured
+// sampler, and leaves everything else to it
+type debugSampler struct {
+	sdktrace.Sampler
+}
+
+func (s debugSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if !isDebug(p.ParentContext) {
+		return s.Sampler.ShouldSample(p)
+	}
+	res := s.Sampler.ShouldSample(p)
+	res.Decision = sdktrace.RecordAndSample
+	res.Attributes = append(res.Attributes, attribute.Bool("app.debug", true))
+	return res
+}
+
+func (s debugSampler) Description() string {
+	return "DebugSampler{" + s.Sampler.Description() + "}"
+}
+
+// debugLevelHandler lets debug records through only for debug requests;
+// everyone else keeps getting info and up. Debug records need the request
+// context, so they must be logged with DebugContext.
+type debugLevelHandler struct {
+	slog.Handler
+}
+
+func (h debugLevelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= slog.LevelInfo || isDebug(ctx)
+}
+
+func (h debugLevelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return debugLevelHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h debugLevelHandler) WithGroup(name string) slog.Handler {
+	return debugLevelHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDebugHeaderForcesDebugLogsAndSampling(t *testing.T) {
+	stub := startStub(t, http.StatusOK)
+	app := startApp(t,
+		"DEBUG_HEADER_ENABLED=true",
+		"OTEL_TRACES_SAMPLER=always_off",
+		"DOWNSTREAM_URL="+stub.URL,
+		"OUTBOUND_PROPAGATORS=tracecontext",
+	)
+
+	app.get("/work")
+	app.get("/work", "X-Debug", "1")
+
+	// Only the debug request is sampled, and its logs go down to debug
+	spans := app.spansNamed("work", 1)
+	if v := spans[0].attr("app.debug"); v != true {
+		t.Errorf("app.debug = %v, want true", v)
+	}
+	debug := app.logsWithMsg("simulating work")
+	if len(debug) != 1 {
+		t.Fatalf("got %d debug records, want 1 for the debug request only", len(debug))
+	}
+	if debug[0]["level"] != "DEBUG" || debug[0]["trace_id"] != spans[0].SpanContext.TraceID {
+		t.Errorf("debug record %v doesn't belong to the sampled trace %s", debug[0], spans[0].SpanContext.TraceID)
+	}
+	for _, s := range app.spans() {
+		if s.SpanContext.TraceID != spans[0].SpanContext.TraceID {
+			t.Errorf("span %q from the plain request was sampled", s.Name)
+		}
+	}
+
+	// The flag goes downstream as baggage even though the outbound
+	// propagators leave baggage out
+	reqs := stub.requests()
+	if len(reqs) != 2 {
+		t.Fatalf("downstream saw %d requests, want 2", len(reqs))
+	}
+	if b := reqs[0].Get("Baggage"); b != "" {
+		t.Errorf("plain request sent baggage %q", b)
+	}
+	if b := reqs[1].Get("Baggage"); !strings.Contains(b, "debug=1") {
+		t.Errorf("debug request sent baggage %q, want debug=1", b)
+	}
+}
+
+func TestDebugHeaderIgnoredWhenDisabled(t *testing.T) {
+	app := startApp(t, "OTEL_TRACES_SAMPLER=always_off")
+	app.get("/work", "X-Debug", "1")
+	app.stop() // flushes anything that was sampled
+
+	for _, l := range app.logs() {
+		if l["level"] == "DEBUG" {
+			t.Errorf("debug record %v without DEBUG_HEADER_ENABLED", l)
+		}
+	}
+	if spans := app.spans(); len(spans) != 0 {
+		t.Errorf("%d spans sampled without DEBUG_HEADER_ENABLED", len(spans))
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Upper bound on the nesting a single /deep request may produce; deep enough
+// to find where a trace UI gives up, shallow enough not to hit span limits
+const maxDepth = 500
+
+// Time each level spends on its own before descending
+const deepSlice = time.Millisecond
+
+// deepHandler builds a single linear chain of depth nested spans, each the
+// parent of the next, for checking how a trace UI renders very deep traces
+func deepHandler(w http.ResponseWriter, r *http.Request) {
+	depth := 10
+	if raw := r.URL.Query().Get("depth"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 1 || v > maxDepth {
+			http.Error(w, fmt.Sprintf("depth must be between 1 and %d", maxDepth), http.StatusBadRequest)
+			return
+		}
+		depth = v
+	}
+
+	ctx := r.Context()
+	log := requestLogger(ctx)
+
+	levelCtx := ctx
+	spans := make([]trace.Span, 0, depth)
+	var err error
+	for level := 1; level <= depth && err == nil; level++ {
+		var span trace.Span
+		levelCtx, span = otel.Tracer("app").Start(levelCtx, "level",
+			trace.WithSpanKind(trace.SpanKindInternal),
+			trace.WithAttributes(attribute.Int("deep.level", level)),
+		)
+		spans = append(spans, span)
+		err = sleepCtx(ctx, deepSlice)
+	}
+	// Innermost first, so every parent ends after its child
+	for _, span := range slices.Backward(spans) {
+		span.End()
+	}
+	if err != nil {
+		abortRequest(ctx, w, "deep", log)
+		return
+	}
+
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("deep.depth", depth))
+	log.Info("deep trace built", "depth", depth)
+	fmt.Fprintf(w, "Built a chain of %d nested spans\n", depth)
+}
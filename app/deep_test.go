@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestDeepTraceIsALinearChain(t *testing.T) {
+	app := startApp(t)
+	if resp, body := app.get("/deep?depth=10"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", resp.StatusCode, body)
+	}
+
+	server := app.spansNamed("deep", 1)[0]
+	app.spansNamed("level", 10)
+	parent := server
+	for level := 1; level <= 10; level++ {
+		children := app.children(parent)
+		if len(children) != 1 {
+			t.Fatalf("span at level %d has %d children, want 1", level-1, len(children))
+		}
+		child := children[0]
+		if child.Name != "level" || child.attr("deep.level") != float64(level) {
+			t.Fatalf("child at level %d is %q with deep.level %v", level, child.Name, child.attr("deep.level"))
+		}
+		parent = child
+	}
+	if n := len(app.children(parent)); n != 0 {
+		t.Errorf("innermost span has %d children, want none", n)
+	}
+	if got := server.attr("deep.depth"); got != float64(10) {
+		t.Errorf("deep.depth = %v, want 10", got)
+	}
+}
+
+func TestDeepTraceDepthBounds(t *testing.T) {
+	app := startApp(t)
+	for _, depth := range []string{"0", "501", "ten"} {
+		if resp, _ := app.get("/deep?depth=" + depth); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("depth=%s: status = %d, want 400", depth, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// A degradation ramps the share of /work requests answered with 503 from 0
+// up to DEGRADE_MAX_RATIO over its ramp, and stays there until stopped: a
+// slow-burning outage instead of the incident's sudden spike.
+var degradation struct {
+	sync.Mutex
+	start time.Time
+	ramp  time.Duration
+}
+
+// degradeRatio is the fraction of /work requests currently failing with 503
+func degradeRatio() float64 {
+	degradation.Lock()
+	defer degradation.Unlock()
+	if degradation.start.IsZero() {
+		return 0
+	}
+	progress := min(1, time.Since(degradation.start).Seconds()/degradation.ramp.Seconds())
+	return cfg.DegradeMaxRatio * progress
+}
+
+type degradeStatus struct {
+	Active      bool    `json:"active"`
+	RampSeconds float64 `json:"ramp_seconds,omitempty"`
+	Ratio503    float64 `json:"ratio_503"`
+}
+
+// degradeHandler starts a degradation on POST (optionally ?ramp=10m), stops
+// it on DELETE and reports the current state on GET
+func degradeHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		d := cfg.DegradeRamp
+		if raw := r.URL.Query().Get("ramp"); raw != "" {
+			v, err := time.ParseDuration(raw)
+			if err != nil || v <= 0 {
+				http.Error(w, "ramp must be a positive Go duration", http.StatusBadRequest)
+				return
+			}
+			d = v
+		}
+		degradation.Lock()
+		degradation.start, degradation.ramp = time.Now(), d
+		degradation.Unlock()
+		logger.Warn("simulated degradation started", "ramp", d.String(), "max_ratio", cfg.DegradeMaxRatio)
+	case http.MethodDelete:
+		degradation.Lock()
+		degradation.start = time.Time{}
+		degradation.Unlock()
+		logger.Info("simulated degradation stopped")
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	degradation.Lock()
+	status := degradeStatus{Active: !degradation.start.IsZero()}
+	if status.Active {
+		status.RampSeconds = degradation.ramp.Seconds()
+	}
+	degradation.Unlock()
+	status.Ratio503 = degradeRatio()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
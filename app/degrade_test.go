@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDegradeRatioRisesOverRamp(t *testing.T) {
+	app := startApp(t, "DEGRADE_MAX_RATIO=1")
+	if resp, body := app.do(http.MethodPost, "/admin/degrade?ramp=2s", nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("starting degradation: %d %s", resp.StatusCode, body)
+	}
+
+	// The reported ratio never goes down while ramping
+	var ratios []float64
+	for i := 0; i < 6; i++ {
+		_, body := app.get("/admin/degrade")
+		var st degradeStatus
+		if err := json.Unmarshal([]byte(body), &st); err != nil {
+			t.Fatalf("decoding %q: %v", body, err)
+		}
+		ratios = append(ratios, st.Ratio503)
+		time.Sleep(300 * time.Millisecond)
+	}
+	for i := 1; i < len(ratios); i++ {
+		if ratios[i] < ratios[i-1] {
+			t.Errorf("ratio fell from %v to %v: %v", ratios[i-1], ratios[i], ratios)
+		}
+	}
+	if ratios[0] >= ratios[len(ratios)-1] {
+		t.Errorf("ratio didn't rise over the ramp: %v", ratios)
+	}
+
+	// Once the ramp is over, /work fails with 503 at the full ratio
+	time.Sleep(500 * time.Millisecond)
+	for _, code := range app.getConcurrently(20, "/work") {
+		if code != http.StatusServiceUnavailable {
+			t.Fatalf("after the ramp /work gave %d, want 503", code)
+		}
+	}
+
+	app.do(http.MethodDelete, "/admin/degrade", nil)
+	for _, code := range app.getConcurrently(20, "/work") {
+		if code != http.StatusOK {
+			t.Fatalf("after stopping /work gave %d, want 200", code)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package main
+
+import "testing"
+
+func TestDeploymentIDOnResourceAndMetrics(t *testing.T) {
+	app := startApp(t, "DEPLOYMENT_ID=rel-42")
+	app.get("/work")
+
+	span := app.spansNamed("work", 1)[0]
+	if got := attrValue(span.Resource, "deployment.id"); got != "rel-42" {
+		t.Errorf("resource deployment.id = %v, want rel-42", got)
+	}
+	if v := app.metricValue("http_request_duration_seconds", "deployment_id", "rel-42"); v < 1 {
+		t.Errorf("request histogram with deployment_id=rel-42 has %v samples, want at least 1", v)
+	}
+}
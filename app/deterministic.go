@@ -0,0 +1,92 @@
+// rand.Seed is a no-op since Go 1.24 unless this is turned back off
+//go:debug randseednop=0
+
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Instant the clock is frozen at under TEST_DETERMINISTIC
+var deterministicEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// now is the clock request timings are taken from. TEST_DETERMINISTIC stops
+// it, so every recorded duration is 0 however long the request really took.
+var now = time.Now
+
+func since(t time.Time) time.Duration { return now().Sub(t) }
+
+// enableDeterministic makes /metrics byte-stable for golden-file tests: the
+// request clock is frozen, the simulation's random choices repeat from run
+// to run, and the Go and process collectors, whose values change on every
+// scrape, are removed
+func enableDeterministic() {
+	now = func() time.Time { return deterministicEpoch }
+	rand.Seed(1)
+	prometheus.Unregister(collectors.NewGoCollector())
+	prometheus.Unregister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+}
+
+// frozenGatherer stamps every exemplar with the frozen clock, since the
+// client library always timestamps exemplars with the real time
+type frozenGatherer struct {
+	prometheus.Gatherer
+}
+
+func (g frozenGatherer) Gather() ([]*dto.MetricFamily, error) {
+	mfs, err := g.Gatherer.Gather()
+	ts := timestamppb.New(deterministicEpoch)
+	for _, mf := range mfs {
+		for _, m := range mf.GetMetric() {
+			for _, b := range m.GetHistogram().GetBucket() {
+				if e := b.GetExemplar(); e != nil {
+					e.Timestamp = ts
+				}
+			}
+			for _, e := range m.GetHistogram().GetExemplars() {
+				e.Timestamp = ts
+			}
+			if e := m.GetCounter().GetExemplar(); e != nil {
+				e.Timestamp = ts
+			}
+		}
+	}
+	return mfs, err
+}
+
+// sequentialIDs hands out trace and span IDs from a counter, so the trace
+// IDs in exemplars and logs are the same on every run
+type sequentialIDs struct {
+	mu   sync.Mutex
+	next uint64
+}
+
+func (g *sequentialIDs) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.next++
+	var tid trace.TraceID
+	binary.BigEndian.PutUint64(tid[8:], g.next)
+	var sid trace.SpanID
+	binary.BigEndian.PutUint64(sid[:], g.next)
+	return tid, sid
+}
+
+func (g *sequentialIDs) NewSpanID(ctx context.Context, traceID trace.TraceID) trace.SpanID {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.next++
+	var sid trace.SpanID
+	binary.BigEndian.PutUint64(sid[:], g.next)
+	return sid
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// deterministicScrapes runs the same sequential traffic against a fresh
+// TEST_DETERMINISTIC instance and returns two scrapes taken after it
+func deterministicScrapes(t *testing.T, collector *fakeCollector) (string, string) {
+	t.Helper()
+	app := startApp(t, "TEST_DETERMINISTIC=true", "FAILURE_RATE=0.3", "OTLP_ENDPOINTS="+collector.addr)
+	for i := 0; i < 8; i++ {
+		app.get("/work")
+	}
+	_, first := app.get("/metrics", "Accept", openMetricsAccept)
+	_, second := app.get("/metrics", "Accept", openMetricsAccept)
+	return first, second
+}
+
+func TestDeterministicScrapeIsStable(t *testing.T) {
+	// One collector for both runs, since its address is a label
+	collector := startCollector(t)
+	first, second := deterministicScrapes(t, collector)
+	if first != second {
+		t.Errorf("two scrapes differ:\n%s", diffLines(first, second))
+	}
+	// Exemplars are there, with sequential trace IDs
+	if !strings.Contains(first, `traceID="0000000000000000`) {
+		t.Error("scrape has no exemplar with a sequential trace ID")
+	}
+
+	// And a second process with the same traffic exposes the same bytes
+	again, _ := deterministicScrapes(t, collector)
+	if again != first {
+		t.Errorf("scrapes of two runs differ:\n%s", diffLines(first, again))
+	}
+}
+
+// diffLines lists the lines of a and b that the other lacks
+func diffLines(a, b string) string {
+	inA := map[string]bool{}
+	for _, l := range strings.Split(a, "\n") {
+		inA[l] = true
+	}
+	inB := map[string]bool{}
+	var out []string
+	for _, l := range strings.Split(b, "\n") {
+		inB[l] = true
+		if !inA[l] {
+			out = append(out, "+ "+l)
+		}
+	}
+	for _, l := range strings.Split(a, "\n") {
+		if !inB[l] {
+			out = append(out, "- "+l)
+		}
+	}
+	return strings.Join(out, "\n")
+}
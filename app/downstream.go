@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var downstreamRetries = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "downstream_retries_total",
+		Help: "Downstream calls that needed retries, by whether they eventually succeeded",
+	},
+	[]string{"outcome"},
+)
+
+var downstreamRateLimited = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "downstream_rate_limited_total",
+		Help: "Downstream responses with 429 Too Many Requests",
+	},
+)
+
+var downstreamClient = &http.Client{}
+
+// slowConnectTransport is the default transport with delay added to every
+// new connection's TCP connect. The pause runs once the socket exists but
+// before it connects, so it falls between the connect_start and
+// connect_done span events rather than before the request starts; reused
+// connections skip it.
+func slowConnectTransport(delay time.Duration) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		ControlContext: func(ctx context.Context, _, _ string, _ syscall.RawConn) error {
+			return sleepCtx(ctx, delay)
+		},
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = dialer.DialContext
+	return t
+}
+
+// rateLimitedError is a 429 from downstream, with the wait it asked for
+type rateLimitedError struct {
+	retryAfter time.Duration
+}
+
+func (e *rateLimitedError) Error() string {
+	return fmt.Sprintf("downstream rate limited, retry after %s", e.retryAfter)
+}
+
+// callDownstream GETs cfg.DownstreamURL, retrying transport errors, 5xx and
+// 429 responses up to cfg.DownstreamMaxRetries times. A 429's Retry-After
+// replaces the usual backoff, unless it's longer than
+// cfg.DownstreamMaxRetryAfter. Retries also need a token from the retry
+// budget. Every attempt is its own client span; the last one carries
+// retry.count.
+func callDownstream(ctx context.Context) error {
+	for retries := 0; ; retries++ {
+		span, retryable, err := downstreamAttempt(ctx, retries)
+		backoff := cfg.DownstreamRetryBackoff
+		var rl *rateLimitedError
+		if errors.As(err, &rl) && rl.retryAfter > 0 {
+			// Waiting longer than we're willing to is the same as giving up
+			backoff = rl.retryAfter
+			retryable = backoff <= cfg.DownstreamMaxRetryAfter
+		}
+		if err != nil && retryable && retries < cfg.DownstreamMaxRetries && ctx.Err() == nil {
+			if takeRetryToken() {
+				span.End()
+				if err := sleepCtx(ctx, backoff); err != nil {
+					return err
+				}
+				continue
+			}
+			retryBudgetExhausted.Inc()
+			span.AddEvent("retry_budget_exhausted")
+		}
+
+		span.SetAttributes(attribute.Int("retry.count", retries))
+		span.End()
+		switch {
+		case retries == 0:
+		case err == nil:
+			downstreamRetries.WithLabelValues("succeeded_after_retry").Inc()
+		case ctx.Err() == nil:
+			downstreamRetries.WithLabelValues("exhausted").Inc()
+		}
+		return err
+	}
+}
+
+// downstreamPeerAttributes name the service on the other end of the call,
+// which service-graph tools (Tempo, Grafana's node graph) need to draw the
+// edge from this app to it. peer.service defaults to the URL's host name.
+func downstreamPeerAttributes() []attribute.KeyValue {
+	u, err := url.Parse(cfg.DownstreamURL)
+	if err != nil {
+		return nil
+	}
+	peer := cfg.DownstreamPeerService
+	if peer == "" {
+		peer = u.Hostname()
+	}
+	port, _ := strconv.Atoi(u.Port())
+	if port == 0 && u.Scheme == "https" {
+		port = 443
+	} else if port == 0 {
+		port = 80
+	}
+	return []attribute.KeyValue{
+		semconv.PeerService(peer),
+		attribute.String("server.address", u.Hostname()),
+		attribute.Int("server.port", port),
+	}
+}
+
+// downstreamAttempt makes a single request. The returned span is still open
+// so the caller can annotate the final attempt.
+func downstreamAttempt(ctx context.Context, retries int) (trace.Span, bool, error) {
+	ctx, span := startClient(ctx, "GET",
+		trace.WithAttributes(
+			semconv.HTTPMethod(http.MethodGet),
+			semconv.HTTPURL(cfg.DownstreamURL),
+		),
+		trace.WithAttributes(downstreamPeerAttributes()...),
+	)
+	if retries > 0 {
+		span.SetAttributes(semconv.HTTPResendCount(retries))
+	}
+
+	fail := func(err error, retryable bool) (trace.Span, bool, error) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return span, retryable, err
+	}
+
+	req, err := http.NewRequestWithContext(withClientTrace(ctx, span), http.MethodGet, cfg.DownstreamURL, nil)
+	if err != nil {
+		return fail(err, false)
+	}
+	outboundPropagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := downstreamClient.Do(req)
+	if err != nil {
+		return fail(err, true)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	span.SetAttributes(semconv.HTTPStatusCode(resp.StatusCode))
+	logger.DebugContext(ctx, "downstream responded",
+		"url", redactURL(cfg.DownstreamURL),
+		"status", resp.StatusCode,
+		"retries", retries,
+		"trace_id", span.SpanContext().TraceID().String(),
+		"span_id", span.SpanContext().SpanID().String(),
+	)
+	if resp.StatusCode == http.StatusTooManyRequests {
+		downstreamRateLimited.Inc()
+		after := parseRetryAfter(resp.Header.Get("Retry-After"))
+		span.AddEvent("rate_limited", trace.WithAttributes(
+			attribute.Int64("http.retry_after_ms", after.Milliseconds()),
+		))
+		return fail(&rateLimitedError{after}, true)
+	}
+	if resp.StatusCode >= 500 {
+		return fail(fmt.Errorf("downstream returned %d", resp.StatusCode), true)
+	}
+	if resp.StatusCode >= 400 {
+		return fail(fmt.Errorf("downstream returned %d", resp.StatusCode), false)
+	}
+	return span, false, nil
+}
+
+// parseRetryAfter reads a Retry-After header in either of its forms, delay
+// seconds or an HTTP date. Anything unparseable counts as no hint.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(0, time.Until(t))
+	}
+	return 0
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+// lastAttempt returns the downstream client span that carries retry.count
+func lastAttempt(t *testing.T, app *testApp) exportedSpan {
+	t.Helper()
+	var last exportedSpan
+	app.waitFor("the final downstream attempt", func() bool {
+		for _, s := range app.spans() {
+			if s.Name == "GET" && s.attr("retry.count") != nil {
+				last = s
+				return true
+			}
+		}
+		return false
+	})
+	return last
+}
+
+func TestDownstreamRecoversAfterRetry(t *testing.T) {
+	stub := startStub(t, http.StatusInternalServerError, http.StatusOK)
+	app := startApp(t, "DOWNSTREAM_URL="+stub.URL, "DOWNSTREAM_RETRY_BACKOFF=1ms")
+
+	// Past the dependency, /work can still fail on its own
+	if resp, _ := app.get("/work"); resp.StatusCode == http.StatusBadGateway {
+		t.Fatal("status = 502 for a call that recovered")
+	}
+	if n := len(stub.requests()); n != 2 {
+		t.Errorf("downstream saw %d requests, want 2", n)
+	}
+	if v := lastAttempt(t, app).attr("retry.count"); v != float64(1) {
+		t.Errorf("retry.count = %v, want 1", v)
+	}
+	if v := app.metricValue("downstream_retries_total", "outcome", "succeeded_after_retry"); v != 1 {
+		t.Errorf("succeeded_after_retry = %v, want 1", v)
+	}
+	if _, ok := seriesValue(app.scrape(), "downstream_retries_total", "outcome", "exhausted"); ok {
+		t.Error("a recovered call was counted as exhausted")
+	}
+}
+
+func TestDownstreamRetriesExhausted(t *testing.T) {
+	stub := startStub(t, http.StatusInternalServerError)
+	app := startApp(t,
+		"DOWNSTREAM_URL="+stub.URL,
+		"DOWNSTREAM_RETRY_BACKOFF=1ms",
+		"DOWNSTREAM_MAX_RETRIES=2",
+	)
+
+	if resp, _ := app.get("/work"); resp.StatusCode != http.StatusBadGateway {
+		t.Fatalf("status = %d, want 502", resp.StatusCode)
+	}
+	if n := len(stub.requests()); n != 3 {
+		t.Errorf("downstream saw %d requests, want 3", n)
+	}
+	if v := lastAttempt(t, app).attr("retry.count"); v != float64(2) {
+		t.Errorf("retry.count = %v, want 2", v)
+	}
+	if v := app.metricValue("downstream_retries_total", "outcome", "exhausted"); v != 1 {
+		t.Errorf("exhausted = %v, want 1", v)
+	}
+}
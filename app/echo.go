@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var sizeBuckets = prometheus.ExponentialBuckets(64, 4, 8)
+
+var echoRequestSize = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Name:    "http_echo_request_size_bytes",
+		Help:    "Size of request bodies received by /echo-body",
+		Buckets: sizeBuckets,
+	},
+)
+
+var echoResponseSize = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Name:    "http_echo_response_size_bytes",
+		Help:    "Size of response bodies written by /echo-body",
+		Buckets: sizeBuckets,
+	},
+)
+
+// echoBodyHandler echoes the request body back after a delay proportional to
+// its size, so payload size and latency visibly correlate
+func echoBodyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	span := trace.SpanFromContext(ctx)
+	log := requestLogger(ctx)
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, cfg.MaxBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	echoRequestSize.Observe(float64(len(body)))
+
+	delay := time.Duration(len(body)) * cfg.EchoDelayPerKB / 1024
+	if err := sleepCtx(ctx, delay); err != nil {
+		abortRequest(ctx, w, "echo_body", log)
+		return
+	}
+
+	span.SetAttributes(
+		semconv.HTTPRequestContentLength(len(body)),
+		semconv.HTTPResponseContentLength(len(body)),
+		attribute.Int64("echo.delay_ms", delay.Milliseconds()),
+		attribute.Int64("echo.delay_per_kb_us", cfg.EchoDelayPerKB.Microseconds()),
+	)
+	log.Info("echoed body", "bytes", len(body), "delay_ms", delay.Milliseconds())
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	n, _ := w.Write(body)
+	echoResponseSize.Observe(float64(n))
+}
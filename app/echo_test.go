@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEchoBodySizeAndLatency(t *testing.T) {
+	app := startApp(t, "ECHO_DELAY_PER_KB=10ms")
+
+	payload := strings.Repeat("x", 20<<10)
+	start := time.Now()
+	resp, body := app.do(http.MethodPost, "/echo-body", strings.NewReader(payload))
+	elapsed := time.Since(start)
+	if resp.StatusCode != http.StatusOK || body != payload {
+		t.Fatalf("status = %d with %d bytes back, want 200 echoing %d", resp.StatusCode, len(body), len(payload))
+	}
+	if elapsed < 200*time.Millisecond {
+		t.Errorf("20KiB echoed in %s, want at least 200ms at 10ms/KiB", elapsed)
+	}
+
+	span := app.spansNamed("echo_body", 1)[0]
+	if v := span.attr("echo.delay_ms"); v != float64(200) {
+		t.Errorf("echo.delay_ms = %v, want 200", v)
+	}
+	if v := span.attr("http.request_content_length"); v != float64(len(payload)) {
+		t.Errorf("http.request_content_length = %v, want %d", v, len(payload))
+	}
+
+	families := app.scrape()
+	for _, name := range []string{"http_echo_request_size_bytes", "http_echo_response_size_bytes"} {
+		h := families[name].GetMetric()[0].GetHistogram()
+		if h.GetSampleCount() != 1 || h.GetSampleSum() != float64(len(payload)) {
+			t.Errorf("%s: count %d sum %v, want 1 observation of %d", name, h.GetSampleCount(), h.GetSampleSum(), len(payload))
+		}
+	}
+}
+
+func TestEchoBodyTooLarge(t *testing.T) {
+	app := startApp(t, "MAX_BODY_BYTES=1024")
+
+	resp, _ := app.do(http.MethodPost, "/echo-body", bytes.NewReader(make([]byte, 2048)))
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want 413", resp.StatusCode)
+	}
+}
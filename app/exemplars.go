@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var exemplarOverwrites = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "exemplar_overwrites_total",
+		Help: "Exemplars that replaced an earlier exemplar in the same request duration bucket",
+	},
+)
+
+var exemplarEligible = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "exemplar_eligible_total",
+		Help: "Request observations from a sampled trace, which could carry an exemplar",
+	},
+)
+
+var exemplarAttached = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "exemplar_attached_total",
+		Help: "Request observations that actually carried an exemplar; attached/eligible is the exemplar coverage",
+	},
+)
+
+var exemplarObserverUnsupported = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "exemplar_observer_unsupported_total",
+		Help: "Request observations that should have carried an exemplar but the histogram doesn't support them; anything above 0 is a misconfiguration",
+	},
+)
+
+// checkExemplarSupport fails start-up when exemplars are on but the request
+// histogram can't take them, instead of quietly serving a /metrics without
+// any. The probe series is deleted again so it never shows up in a scrape.
+func checkExemplarSupport(vec *prometheus.HistogramVec) {
+	if !cfg.EnableExemplars {
+		return
+	}
+	probe := prometheus.Labels{}
+	for _, name := range reqLabelNames() {
+		probe[name] = "probe"
+	}
+	obs := vec.With(probe)
+	vec.Delete(probe)
+	if err := exemplarSupport(obs); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// exemplarSupport explains why obs can't carry exemplars, or returns nil
+func exemplarSupport(obs prometheus.Observer) error {
+	if _, ok := obs.(prometheus.ExemplarObserver); !ok {
+		return fmt.Errorf("ENABLE_EXEMPLARS is set but %T does not support exemplars; set ENABLE_EXEMPLARS=false or fix the histogram setup", obs)
+	}
+	return nil
+}
+
+// observeRequest records one request duration on obs, attaching the trace
+// ID (and log ID) as an exemplar when the request is eligible and its bucket
+// of series has room for one
+func observeRequest(log *slog.Logger, obs prometheus.Observer, eligible bool, series, bucket string, duration float64, traceID, logID string) {
+	exemplarObs, ok := obs.(prometheus.ExemplarObserver)
+	switch {
+	case !eligible:
+		obs.Observe(duration)
+	case !ok:
+		exemplarObserverUnsupported.Inc()
+		log.Warn("Exemplar not supported", "traceID", traceID)
+		obs.Observe(duration)
+	case claimExemplarSlot(series, bucket):
+		log.Info("Attaching exemplar", "traceID", traceID, "duration", duration)
+		exemplarObs.ObserveWithExemplar(duration, exemplarLabels(traceID, logID))
+		exemplarAttached.Inc()
+	default:
+		obs.Observe(duration)
+	}
+}
+
+// The client library keeps one exemplar per histogram bucket and the newest
+// always wins. Tracking when each bucket last got one lets us count
+// overwrites and, with EXEMPLAR_MIN_AGE, keep an exemplar around for a while
+// instead of replacing it on every request.
+var exemplarSlots = struct {
+	sync.Mutex
+	last map[string]time.Time
+}{last: map[string]time.Time{}}
+
+// claimExemplarSlot reports whether an observation landing in bucket of
+// series should carry an exemplar
+func claimExemplarSlot(series, bucket string) bool {
+	key := series + "/" + bucket
+	now := time.Now()
+
+	exemplarSlots.Lock()
+	defer exemplarSlots.Unlock()
+	prev, ok := exemplarSlots.last[key]
+	if ok && now.Sub(prev) < cfg.ExemplarMinAge {
+		return false
+	}
+	if ok {
+		exemplarOverwrites.Inc()
+	}
+	exemplarSlots.last[key] = now
+	return true
+}
+
+// newLogID returns a random ID for one log record. It comes from math/rand
+// so TEST_DETERMINISTIC runs get the same IDs.
+func newLogID() string {
+	return fmt.Sprintf("%016x", rand.Uint64())
+}
+
+// exemplarLabels are the labels of a request's exemplar: its trace ID, plus
+// the ID of its outcome log line when there is one
+func exemplarLabels(traceID, logID string) prometheus.Labels {
+	labels := prometheus.Labels{"traceID": traceID}
+	if logID != "" {
+		labels["log_id"] = logID
+	}
+	return labels
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const openMetricsAccept = "application/openmetrics-text; version=1.0.0"
+
+func TestExemplarsDisabled(t *testing.T) {
+	app := startApp(t, "ENABLE_EXEMPLARS=false")
+
+	app.get("/work")
+	resp, body := app.get("/metrics", "Accept", openMetricsAccept)
+	if ct := resp.Header.Get("Content-Type"); strings.HasPrefix(ct, "application/openmetrics-text") {
+		t.Errorf("Content-Type = %q, want the classic text format", ct)
+	}
+	if strings.Contains(body, "# {") {
+		t.Error("scrape contains exemplars with ENABLE_EXEMPLARS=false")
+	}
+}
+
+func TestExemplarsEnabled(t *testing.T) {
+	app := startApp(t)
+
+	app.get("/work")
+	traceID := app.spansNamed("work", 1)[0].SpanContext.TraceID
+	_, body := app.get("/metrics", "Accept", openMetricsAccept)
+	if !strings.Contains(body, `# {traceID="`+traceID+`"}`) {
+		t.Errorf("scrape has no exemplar for trace %s with exemplars enabled", traceID)
+	}
+}
+
+func TestExemplarOverwritesCounted(t *testing.T) {
+	app := startApp(t)
+
+	// 20 requests of one series can't each get a bucket of their own among
+	// the 12 (+Inf included), so at least 8 replace an earlier exemplar
+	app.getConcurrently(20, "/work")
+	if v := app.metricValue("exemplar_overwrites_total"); v < 8 {
+		t.Errorf("exemplar_overwrites_total = %v after 20 requests, want at least 8", v)
+	}
+}
+
+func TestExemplarMinAgeKeepsExemplar(t *testing.T) {
+	app := startApp(t, "EXEMPLAR_MIN_AGE=1h")
+
+	app.getConcurrently(20, "/work")
+	if v := app.metricValue("exemplar_overwrites_total"); v != 0 {
+		t.Errorf("exemplar_overwrites_total = %v with EXEMPLAR_MIN_AGE=1h, want 0", v)
+	}
+}
+
+func TestExemplarEligibleVsAttached(t *testing.T) {
+	app := startApp(t, "EXEMPLAR_MIN_AGE=1h")
+
+	// Only the first request into each of the 12 buckets gets to attach one
+	app.getConcurrently(20, "/work")
+	families := app.scrape()
+	eligible, _ := seriesValue(families, "exemplar_eligible_total")
+	attached, _ := seriesValue(families, "exemplar_attached_total")
+	if eligible != 20 {
+		t.Errorf("exemplar_eligible_total = %v, want 20", eligible)
+	}
+	if attached < 1 || attached > 12 {
+		t.Errorf("exemplar_attached_total = %v, want between 1 and 12", attached)
+	}
+}
+
+func TestUnsampledRequestsNotEligible(t *testing.T) {
+	app := startApp(t, "OTEL_TRACES_SAMPLER=always_off")
+
+	app.get("/work")
+	families := app.scrape()
+	for _, name := range []string{"exemplar_eligible_total", "exemplar_attached_total"} {
+		if v, _ := seriesValue(families, name); v != 0 {
+			t.Errorf("%s = %v for an unsampled request, want 0", name, v)
+		}
+	}
+}
+
+func TestExemplarsOnlyForErrors(t *testing.T) {
+	// Without the status label, 200s and 500s fall into the same buckets
+	app := startApp(t, "EXEMPLAR_MODE=errors", "FAILURE_RATE=0.5", "DROP_REQUEST_LABELS=status")
+
+	codes := app.getConcurrently(30, "/work")
+	failed := 0
+	for _, c := range codes {
+		if c >= 500 {
+			failed++
+		}
+	}
+	if failed == 0 || failed == len(codes) {
+		t.Fatalf("want a mix of statuses, got %v", codes)
+	}
+
+	status := map[string]any{}
+	for _, s := range app.spansNamed("work", len(codes)) {
+		status[s.SpanContext.TraceID] = s.attr("http.response.status_code")
+	}
+	_, body := app.get("/metrics", "Accept", openMetricsAccept)
+	exemplars := regexp.MustCompile(`http_request_duration_seconds_bucket\{.*# \{traceID="(\w+)"\}`).FindAllStringSubmatch(body, -1)
+	if len(exemplars) == 0 {
+		t.Fatal("no exemplars attached despite failing requests")
+	}
+	for _, m := range exemplars {
+		if code, _ := status[m[1]].(float64); code < 500 {
+			t.Errorf("exemplar trace %s has status %v, want an error request", m[1], status[m[1]])
+		}
+	}
+}
+
+func TestUnsupportedExemplarObserver(t *testing.T) {
+	// A bare Observer, as a histogram without exemplar support would return
+	var observed []float64
+	plain := prometheus.ObserverFunc(func(v float64) { observed = append(observed, v) })
+
+	err := exemplarSupport(plain)
+	if err == nil || !strings.Contains(err.Error(), "does not support exemplars") {
+		t.Errorf("startup check = %v, want a does-not-support-exemplars error", err)
+	}
+	if err := exemplarSupport(newReqDuration(false).With(prometheus.Labels{"method": "GET", "status": "200"})); err != nil {
+		t.Errorf("startup check on the request histogram = %v, want nil", err)
+	}
+
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, nil))
+	before := testutil.ToFloat64(exemplarObserverUnsupported)
+	observeRequest(log, plain, true, "GET 200", "0.1", 0.05, "4bf92f3577b34da6a3ce929d0e0e4736", "")
+
+	if v := testutil.ToFloat64(exemplarObserverUnsupported) - before; v != 1 {
+		t.Errorf("exemplar_observer_unsupported_total went up by %v, want 1", v)
+	}
+	if len(observed) != 1 || observed[0] != 0.05 {
+		t.Errorf("observed %v, want the duration recorded without an exemplar", observed)
+	}
+	if !strings.Contains(buf.String(), `"msg":"Exemplar not supported"`) {
+		t.Errorf("log = %q, want an Exemplar not supported warning", buf.String())
+	}
+}
+
+func TestExemplarLogID(t *testing.T) {
+	app := startApp(t, "EXEMPLAR_LOG_ID=true")
+
+	app.get("/work")
+	traceID := app.spansNamed("work", 1)[0].SpanContext.TraceID
+	_, body := app.get("/metrics", "Accept", openMetricsAccept)
+	// Exemplar labels come out in no particular order
+	var logID string
+	for _, m := range regexp.MustCompile(`http_request_duration_seconds_bucket\{.*# \{([^}]*)\}`).FindAllStringSubmatch(body, -1) {
+		if strings.Contains(m[1], `traceID="`+traceID+`"`) {
+			if id := regexp.MustCompile(`log_id="(\w+)"`).FindStringSubmatch(m[1]); id != nil {
+				logID = id[1]
+			}
+		}
+	}
+	if logID == "" {
+		t.Fatalf("scrape has no exemplar with a log_id for trace %s", traceID)
+	}
+
+	records := app.logsWithMsg("request succeeded")
+	if len(records) != 1 {
+		t.Fatalf("got %d request outcome lines, want 1", len(records))
+	}
+	if records[0]["log_id"] != logID || records[0]["trace_id"] != traceID {
+		t.Errorf("outcome line %v doesn't match the exemplar's log_id %s and trace %s", records[0], logID, traceID)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestEveryEndpointReceivesTelemetry(t *testing.T) {
+	first, second := startCollector(t), startCollector(t)
+	app := startApp(t,
+		"OTLP_ENDPOINTS="+first.addr+","+second.addr,
+		"OTEL_METRIC_EXPORT_INTERVAL=100",
+		"OTEL_BSP_SCHEDULE_DELAY=100",
+	)
+
+	if resp, _ := app.get("/fanout"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	for _, c := range []*fakeCollector{first, second} {
+		app.waitFor("metrics on both collectors", func() bool {
+			_, ok := c.metricScopes()["http.server.request.duration"]
+			return ok
+		})
+		app.waitFor("spans on both collectors", func() bool {
+			return len(c.resourceSpans()) > 0
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+var exportBatchesWaiting = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "trace_export_batches_waiting",
+		Help: "Span batches waiting for an export slot; above 0 means the exporters are the bottleneck",
+	},
+)
+
+var exportBatchesInFlight = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "trace_export_batches_in_flight",
+		Help: "Span batches currently being exported",
+	},
+)
+
+// Slots shared by every trace exporter; nil when exports are unbounded
+var exportSlots chan struct{}
+
+// exportLimiter caps how many span batches are exported at once across all
+// exporters, making the backlog visible as exportBatchesWaiting
+type exportLimiter struct {
+	sdktrace.SpanExporter
+}
+
+func (e exportLimiter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	if exportSlots != nil {
+		exportBatchesWaiting.Inc()
+		select {
+		case exportSlots <- struct{}{}:
+			exportBatchesWaiting.Dec()
+		case <-ctx.Done():
+			exportBatchesWaiting.Dec()
+			return ctx.Err()
+		}
+		defer func() { <-exportSlots }()
+	}
+	exportBatchesInFlight.Inc()
+	defer exportBatchesInFlight.Dec()
+	return e.SpanExporter.ExportSpans(ctx, spans)
+}
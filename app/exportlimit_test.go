@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// blockingExporter holds every export until release is closed
+type blockingExporter struct {
+	sdktrace.SpanExporter
+	release chan struct{}
+}
+
+func (e blockingExporter) ExportSpans(ctx context.Context, _ []sdktrace.ReadOnlySpan) error {
+	<-e.release
+	return nil
+}
+
+func TestExportLimitBackpressureGauge(t *testing.T) {
+	exportSlots = make(chan struct{}, 2)
+	defer func() { exportSlots = nil }()
+
+	release := make(chan struct{})
+	limiter := exportLimiter{blockingExporter{release: release}}
+	const batches = 6
+	done := make(chan error, batches)
+	for i := 0; i < batches; i++ {
+		go func() { done <- limiter.ExportSpans(context.Background(), nil) }()
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for testutil.ToFloat64(exportBatchesWaiting) != batches-2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("batches waiting = %v, want %d", testutil.ToFloat64(exportBatchesWaiting), batches-2)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if v := testutil.ToFloat64(exportBatchesInFlight); v != 2 {
+		t.Errorf("batches in flight = %v, want the limit of 2", v)
+	}
+
+	close(release)
+	for i := 0; i < batches; i++ {
+		if err := <-done; err != nil {
+			t.Errorf("export failed: %v", err)
+		}
+	}
+	if v := testutil.ToFloat64(exportBatchesWaiting); v != 0 {
+		t.Errorf("batches waiting = %v after the exports drained, want 0", v)
+	}
+}
+
+func TestExportLimitGivesUpOnCancel(t *testing.T) {
+	exportSlots = make(chan struct{}, 1)
+	defer func() { exportSlots = nil }()
+	exportSlots <- struct{}{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := (exportLimiter{blockingExporter{}}).ExportSpans(ctx, nil); err != context.Canceled {
+		t.Errorf("err = %v, want context.Canceled while no slot is free", err)
+	}
+	if v := testutil.ToFloat64(exportBatchesWaiting); v != 0 {
+		t.Errorf("batches waiting = %v after giving up, want 0", v)
+	}
+}
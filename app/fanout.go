@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Upper bound on concurrent subtasks a single /fanout request may launch
+const maxFanout = 20
+
+func fanoutHandler(w http.ResponseWriter, r *http.Request) {
+	n := 3
+	if raw := r.URL.Query().Get("n"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 1 || v > maxFanout {
+			http.Error(w, fmt.Sprintf("n must be between 1 and %d", maxFanout), http.StatusBadRequest)
+			return
+		}
+		n = v
+	}
+
+	ctx := r.Context()
+	span := trace.SpanFromContext(ctx)
+	log := requestLogger(ctx)
+
+	// Each subtask gets its own child span under the request span
+	latencies := make([]time.Duration, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, childSpan := otel.Tracer("app").Start(ctx, "subtask",
+				trace.WithSpanKind(trace.SpanKindInternal),
+				trace.WithAttributes(attribute.Int("fanout.index", i)),
+			)
+			latency := time.Duration(rand.Intn(400)) * time.Millisecond
+			sleepCtx(ctx, latency)
+			latencies[i] = latency
+			childSpan.End()
+		}(i)
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		abortRequest(ctx, w, "fanout", log)
+		return
+	}
+
+	// The slowest subtask bounds the whole request: that's the critical path
+	slowest := 0
+	for i, l := range latencies {
+		if l > latencies[slowest] {
+			slowest = i
+		}
+	}
+	span.SetAttributes(
+		attribute.Int("fanout.count", n),
+		attribute.Int("fanout.critical_path.index", slowest),
+		attribute.Int64("fanout.critical_path.latency_ms", latencies[slowest].Milliseconds()),
+	)
+
+	log.Info("fanout completed",
+		"count", n,
+		"critical_path_index", slowest,
+		"critical_path_ms", latencies[slowest].Milliseconds(),
+	)
+	fmt.Fprintf(w, "Fanout of %d completed, critical path subtask %d took %dms\n",
+		n, slowest, latencies[slowest].Milliseconds())
+}
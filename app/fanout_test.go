@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestFanoutChildSpans(t *testing.T) {
+	app := startApp(t)
+
+	resp, body := app.get("/fanout?n=3")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, body %q", resp.StatusCode, body)
+	}
+
+	parent := app.spansNamed("fanout", 1)[0]
+	app.spansNamed("subtask", 3)
+	var subtasks int
+	for _, c := range app.children(parent) {
+		if c.Name == "subtask" {
+			subtasks++
+		}
+	}
+	if subtasks != 3 {
+		t.Errorf("subtask children = %d, want 3", subtasks)
+	}
+	if v := parent.attr("fanout.count"); v != float64(3) {
+		t.Errorf("fanout.count = %v, want 3", v)
+	}
+	idx, ok := parent.attr("fanout.critical_path.index").(float64)
+	if !ok || idx < 0 || idx > 2 {
+		t.Errorf("fanout.critical_path.index = %v, want one of 0, 1, 2", parent.attr("fanout.critical_path.index"))
+	}
+}
+
+func TestFanoutRejectsBadCount(t *testing.T) {
+	app := startApp(t)
+
+	for _, n := range []string{"0", "21", "x"} {
+		if resp, _ := app.get("/fanout?n=" + n); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("n=%s: status = %d, want 400", n, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Flag /work consults: with variant "on" its simulated work takes a quarter
+// of the usual time
+const workFastPathFlag = "work_fast_path"
+
+const flagProvider = "sandbox"
+
+// One variant of a flag and its share of evaluations, relative to the other
+// variants' weights
+type flagVariant struct {
+	name   string
+	weight float64
+}
+
+// MarshalText logs a variant the way FEATURE_FLAGS spells it
+func (v flagVariant) MarshalText() ([]byte, error) {
+	return []byte(v.name + ":" + strconv.FormatFloat(v.weight, 'g', -1, 64)), nil
+}
+
+var flagEvaluations = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "feature_flag_evaluations_total",
+		Help: "Feature flag evaluations, by flag and the variant they returned",
+	},
+	[]string{"flag", "variant"},
+)
+
+// evaluateFlag picks a variant of a FEATURE_FLAGS flag at random by weight
+// and records the evaluation as a feature_flag event on the current span.
+// Flags missing from FEATURE_FLAGS return def, and aren't recorded.
+func evaluateFlag(ctx context.Context, key, def string) string {
+	variants := cfg.FeatureFlags[key]
+	if len(variants) == 0 {
+		return def
+	}
+	var total float64
+	for _, v := range variants {
+		total += v.weight
+	}
+	variant := variants[len(variants)-1].name
+	pick := rand.Float64() * total
+	for _, v := range variants {
+		if pick < v.weight {
+			variant = v.name
+			break
+		}
+		pick -= v.weight
+	}
+
+	trace.SpanFromContext(ctx).AddEvent("feature_flag", trace.WithAttributes(
+		attribute.String("feature_flag.key", key),
+		attribute.String("feature_flag.variant", variant),
+		attribute.String("feature_flag.provider_name", flagProvider),
+	))
+	flagEvaluations.WithLabelValues(key, variant).Inc()
+	return variant
+}
@@ -0,0 +1,52 @@
+package main
+
+import "testing"
+
+func TestFeatureFlagEventsAndCounter(t *testing.T) {
+	app := startApp(t, "FEATURE_FLAGS=work_fast_path=on:1,work_fast_path=off:1")
+	const n = 20
+	app.getConcurrently(n, "/work")
+
+	variants := map[string]float64{}
+	for _, s := range app.spansNamed("work", n) {
+		var found int
+		for _, e := range s.Events {
+			if e.Name != "feature_flag" {
+				continue
+			}
+			found++
+			if key := attrValue(e.Attributes, "feature_flag.key"); key != "work_fast_path" {
+				t.Errorf("feature_flag.key = %v, want work_fast_path", key)
+			}
+			if p := attrValue(e.Attributes, "feature_flag.provider_name"); p != "sandbox" {
+				t.Errorf("feature_flag.provider_name = %v, want sandbox", p)
+			}
+			v, _ := attrValue(e.Attributes, "feature_flag.variant").(string)
+			variants[v]++
+		}
+		if found != 1 {
+			t.Errorf("work span has %d feature_flag events, want 1", found)
+		}
+	}
+	if variants["on"]+variants["off"] != n {
+		t.Fatalf("variants = %v, want only on and off", variants)
+	}
+	for _, v := range []string{"on", "off"} {
+		if got, _ := seriesValue(app.scrape(), "feature_flag_evaluations_total", "flag", "work_fast_path", "variant", v); got != variants[v] {
+			t.Errorf("feature_flag_evaluations_total{variant=%q} = %v, want the %v events", v, got, variants[v])
+		}
+	}
+}
+
+func TestUnconfiguredFlagNotRecorded(t *testing.T) {
+	app := startApp(t)
+	app.get("/work")
+	for _, e := range app.spansNamed("work", 1)[0].Events {
+		if e.Name == "feature_flag" {
+			t.Errorf("feature_flag event %+v without FEATURE_FLAGS", e)
+		}
+	}
+	if _, ok := seriesValue(app.scrape(), "feature_flag_evaluations_total"); ok {
+		t.Error("evaluation counted without FEATURE_FLAGS")
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+var startupFirstExport = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "startup_first_export_seconds",
+		Help: "Time from process start to the first successful OTLP span export",
+	},
+)
+
+var firstExportOnce sync.Once
+
+// firstExportRecorder wraps a span exporter and sets startupFirstExport the
+// first time any wrapped exporter succeeds
+type firstExportRecorder struct {
+	sdktrace.SpanExporter
+}
+
+func (e firstExportRecorder) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+	if err == nil && len(spans) > 0 {
+		firstExportOnce.Do(func() {
+			d := time.Since(startTime)
+			startupFirstExport.Set(d.Seconds())
+			logger.Info("first span export succeeded", "since_start_ms", d.Milliseconds())
+		})
+	}
+	return err
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFirstExportGauge(t *testing.T) {
+	start := time.Now()
+	app := startApp(t, "OTEL_BSP_SCHEDULE_DELAY=50")
+
+	if v := app.metricValue("startup_first_export_seconds"); v != 0 {
+		t.Fatalf("startup_first_export_seconds = %v before any span, want 0", v)
+	}
+	app.get("/healthz")
+	app.logsWithMsg("first span export succeeded")
+	if len(app.collector.resourceSpans()) == 0 {
+		t.Fatal("collector has no spans after the first export")
+	}
+	v := app.metricValue("startup_first_export_seconds")
+	if v <= 0 || v > time.Since(start).Seconds() {
+		t.Errorf("startup_first_export_seconds = %v, want a positive value within the test's %s", v, time.Since(start))
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// flushTimeoutError is returned when a signal's provider didn't finish
+// exporting before the shutdown flush deadline; whatever it still held is lost
+type flushTimeoutError struct {
+	signal  string
+	timeout time.Duration
+}
+
+func (e *flushTimeoutError) Error() string {
+	return fmt.Sprintf("%s flush timed out after %s", e.signal, e.timeout)
+}
+
+// flushError is returned when a signal's provider failed to shut down for any
+// other reason, usually its exporter rejecting the final batch
+type flushError struct {
+	signal string
+	err    error
+}
+
+func (e *flushError) Error() string { return fmt.Sprintf("%s flush failed: %v", e.signal, e.err) }
+func (e *flushError) Unwrap() error { return e.err }
+
+// flushSignal shuts one provider down within timeout, on a context of its own
+// so an expired parent can't make a healthy flush look like a failure, and
+// logs which of the three ways it went
+func flushSignal(signal string, timeout time.Duration, shutdown func(context.Context) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	start := time.Now()
+	err := shutdown(ctx)
+	switch {
+	case err == nil:
+		logger.Info("telemetry flushed", "signal", signal, "duration_ms", time.Since(start).Milliseconds())
+		return nil
+	case errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil:
+		logger.Warn("telemetry flush timed out", "signal", signal, "timeout", timeout.String())
+		return &flushTimeoutError{signal: signal, timeout: timeout}
+	default:
+		logger.Error("telemetry flush failed", "signal", signal, "error", err)
+		return &flushError{signal: signal, err: err}
+	}
+}
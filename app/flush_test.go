@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFlushSignalOutcomes(t *testing.T) {
+	var logs bytes.Buffer
+	defer func(l *slog.Logger) { logger = l }(logger)
+	logger = slog.New(slog.NewJSONHandler(&logs, nil))
+
+	if err := flushSignal("metrics", time.Second, func(context.Context) error { return nil }); err != nil {
+		t.Errorf("clean flush returned %v", err)
+	}
+
+	err := flushSignal("traces", 50*time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	var timeout *flushTimeoutError
+	if !errors.As(err, &timeout) || timeout.signal != "traces" || timeout.timeout != 50*time.Millisecond {
+		t.Errorf("stuck flush returned %#v, want a *flushTimeoutError for traces after 50ms", err)
+	}
+
+	rejected := errors.New("permission denied")
+	err = flushSignal("logs", time.Second, func(context.Context) error { return rejected })
+	var failed *flushError
+	if !errors.As(err, &failed) || !errors.Is(err, rejected) {
+		t.Errorf("failed flush returned %#v, want a *flushError wrapping the exporter's", err)
+	}
+
+	for _, want := range []string{
+		`"msg":"telemetry flushed","signal":"metrics"`,
+		`"msg":"telemetry flush timed out","signal":"traces","timeout":"50ms"`,
+		`"msg":"telemetry flush failed","signal":"logs","error":"permission denied"`,
+	} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("no %s in the logs:\n%s", want, logs.String())
+		}
+	}
+}
+
+func TestShutdownFlushTimeoutExitsNonZero(t *testing.T) {
+	collector := startCollector(t)
+	collector.hold = make(chan struct{})
+	defer close(collector.hold)
+	app := startApp(t, "OTLP_ENDPOINTS="+collector.addr, "SHUTDOWN_FLUSH_TIMEOUT=300ms")
+	app.get("/healthz")
+	app.spansNamed("healthz", 1)
+
+	if code := app.stop(); code != 1 {
+		t.Errorf("exit code = %d, want 1 when the trace flush times out", code)
+	}
+	var timedOut, incomplete bool
+	for _, l := range app.logs() {
+		switch l["msg"] {
+		case "telemetry flush timed out":
+			timedOut = timedOut || (l["signal"] == "traces" && l["timeout"] == "300ms")
+		case "telemetry shutdown incomplete":
+			incomplete = strings.Contains(l["error"].(string), "traces flush timed out after 300ms")
+		}
+	}
+	if !timedOut {
+		t.Error("no flush timed out warning for traces")
+	}
+	if !incomplete {
+		t.Error("no shutdown incomplete error naming the trace timeout")
+	}
+}
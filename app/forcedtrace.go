@@ -0,0 +1,64 @@
+package main
+
+import (
+	crand "crypto/rand"
+	"encoding/json"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const forcedTraceRoute = "admin_forced_trace"
+
+type forcedTraceResult struct {
+	TraceID string `json:"trace_id"`
+	SpanID  string `json:"span_id"`
+}
+
+// withForcedTraceID runs outside otelhttp on the forced-trace route and turns
+// ?trace_id= into a sampled remote parent, so the server span otelhttp starts
+// lands in the requested trace. The parent replaces the incoming propagation
+// headers: left in the request context instead, it would hand otelhttp its
+// no-op tracer provider and the server span would never be recorded.
+func withForcedTraceID(next http.Handler, route string) http.Handler {
+	if route != forcedTraceRoute {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceID, err := trace.TraceIDFromHex(r.URL.Query().Get("trace_id"))
+		if err != nil {
+			http.Error(w, "trace_id must be 32 lowercase hex characters and not all zeros", http.StatusBadRequest)
+			return
+		}
+		var parentID trace.SpanID
+		crand.Read(parentID[:])
+		parent := trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    traceID,
+			SpanID:     parentID,
+			TraceFlags: trace.FlagsSampled,
+			Remote:     true,
+		})
+
+		r = r.Clone(r.Context())
+		for _, field := range inboundPropagator.Fields() {
+			r.Header.Del(field)
+		}
+		inboundPropagator.Inject(trace.ContextWithRemoteSpanContext(r.Context(), parent), propagation.HeaderCarrier(r.Header))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// forcedTraceHandler reports the IDs of the server span started under the
+// forced trace ID, so test automation can look the trace up in the backend
+func forcedTraceHandler(w http.ResponseWriter, r *http.Request) {
+	span := trace.SpanFromContext(r.Context())
+	span.SetAttributes(attribute.Bool("app.forced_trace_id", true))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(forcedTraceResult{
+		TraceID: span.SpanContext().TraceID().String(),
+		SpanID:  span.SpanContext().SpanID().String(),
+	})
+}
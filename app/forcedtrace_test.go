@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestForcedTraceID(t *testing.T) {
+	const traceID = "0af7651916cd43dd8448eb211c80319c"
+	for _, propagators := range []string{"tracecontext,baggage", "b3"} {
+		t.Run(propagators, func(t *testing.T) {
+			// Neither the sampler nor a conflicting traceparent may win
+			app := startApp(t, "OTEL_PROPAGATORS="+propagators, "OTEL_TRACES_SAMPLER=parentbased_always_off")
+			resp, body := app.get("/admin/forced-trace?trace_id="+traceID,
+				"traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d: %s", resp.StatusCode, body)
+			}
+			var res forcedTraceResult
+			if err := json.Unmarshal([]byte(body), &res); err != nil {
+				t.Fatalf("decoding %q: %v", body, err)
+			}
+			if res.TraceID != traceID {
+				t.Errorf("response trace_id = %s, want %s", res.TraceID, traceID)
+			}
+
+			span := app.spansNamed(forcedTraceRoute, 1)[0]
+			if span.SpanContext.TraceID != traceID || span.SpanContext.SpanID != res.SpanID {
+				t.Errorf("exported span %s/%s, want %s/%s", span.SpanContext.TraceID, span.SpanContext.SpanID, traceID, res.SpanID)
+			}
+			if v := span.attr("app.forced_trace_id"); v != true {
+				t.Errorf("app.forced_trace_id = %v, want true", v)
+			}
+		})
+	}
+}
+
+func TestForcedTraceIDValidated(t *testing.T) {
+	app := startApp(t)
+	for _, id := range []string{"", "xyz", "00000000000000000000000000000000", "0af7651916cd43dd8448eb211c80319"} {
+		if resp, _ := app.get("/admin/forced-trace?trace_id=" + id); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("trace_id=%q gave %d, want 400", id, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// globalAttributes stamps GLOBAL_SPAN_ATTRIBUTES onto every span as it
+// starts, so cross-cutting ones like team ownership don't have to be set by
+// each piece of instrumentation. A span setting the same key itself later
+// wins. Unlike resource attributes, these are searchable as span attributes
+// in backends that treat the two differently.
+type globalAttributes struct {
+	attrs []attribute.KeyValue
+}
+
+func newGlobalAttributes(values map[string]string) globalAttributes {
+	var attrs []attribute.KeyValue
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		attrs = append(attrs, attribute.String(key, values[key]))
+	}
+	return globalAttributes{attrs: attrs}
+}
+
+func (g globalAttributes) OnStart(_ context.Context, s sdktrace.ReadWriteSpan) {
+	s.SetAttributes(g.attrs...)
+}
+func (globalAttributes) OnEnd(sdktrace.ReadOnlySpan)      {}
+func (globalAttributes) Shutdown(context.Context) error   { return nil }
+func (globalAttributes) ForceFlush(context.Context) error { return nil }
@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestGlobalSpanAttributesOnEverySpan(t *testing.T) {
+	app := startApp(t, "GLOBAL_SPAN_ATTRIBUTES=team=payments,cost.center=42", "CACHE_HIT_RATIO=0")
+	app.get("/work")
+
+	server := app.spansNamed("work", 1)[0]
+	app.spansNamed("db_query", 1)
+	trace := []exportedSpan{server}
+	for i := 0; i < len(trace); i++ {
+		trace = append(trace, app.children(trace[i])...)
+	}
+	if len(trace) < 3 {
+		t.Fatalf("work trace has %d spans, want the server span and its work spans", len(trace))
+	}
+	for _, s := range trace {
+		if s.attr("team") != "payments" || s.attr("cost.center") != "42" {
+			t.Errorf("span %q has team=%v cost.center=%v, want payments and 42", s.Name, s.attr("team"), s.attr("cost.center"))
+		}
+	}
+}
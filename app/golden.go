@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var startTime = time.Now()
+
+type goldenSignals struct {
+	Latency struct {
+		P50 float64 `json:"p50_seconds"`
+		P95 float64 `json:"p95_seconds"`
+		P99 float64 `json:"p99_seconds"`
+	} `json:"latency"`
+	Traffic struct {
+		Requests          uint64  `json:"requests"`
+		RequestsPerSecond float64 `json:"requests_per_second"`
+	} `json:"traffic"`
+	Errors struct {
+		Errors uint64  `json:"5xx"`
+		Ratio  float64 `json:"ratio"`
+	} `json:"errors"`
+	Saturation struct {
+		InFlight int64    `json:"in_flight"`
+		Limit    int      `json:"limit"`
+		Ratio    *float64 `json:"ratio"`
+	} `json:"saturation"`
+}
+
+// goldenHandler summarizes the four golden signals from the /work request
+// histogram. Errors come from the SLO counters instead, which count 5xx
+// even when DROP_REQUEST_LABELS leaves status off the histogram. Traffic
+// and errors are averaged over the process lifetime.
+func goldenHandler(w http.ResponseWriter, r *http.Request) {
+	var g goldenSignals
+
+	buckets := map[float64]uint64{}
+	for _, m := range collectMetrics(reqDuration) {
+		h := m.GetHistogram()
+		g.Traffic.Requests += h.GetSampleCount()
+		for _, b := range h.GetBucket() {
+			buckets[b.GetUpperBound()] += b.GetCumulativeCount()
+		}
+	}
+
+	g.Latency.P50 = bucketQuantile(0.50, buckets, g.Traffic.Requests)
+	g.Latency.P95 = bucketQuantile(0.95, buckets, g.Traffic.Requests)
+	g.Latency.P99 = bucketQuantile(0.99, buckets, g.Traffic.Requests)
+
+	g.Traffic.RequestsPerSecond = float64(g.Traffic.Requests) / time.Since(startTime).Seconds()
+	sloTotal := counterValue(sloRequests)
+	g.Errors.Errors = uint64(sloTotal - counterValue(sloGoodRequests))
+	if sloTotal > 0 {
+		g.Errors.Ratio = float64(g.Errors.Errors) / sloTotal
+	}
+
+	g.Saturation.InFlight = inFlightCount.Load()
+	g.Saturation.Limit = cfg.MaxConcurrentRequests
+	// Without a limit there is nothing to be saturated against
+	if g.Saturation.Limit > 0 {
+		ratio := float64(g.Saturation.InFlight) / float64(g.Saturation.Limit)
+		g.Saturation.Ratio = &ratio
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(g)
+}
+
+// bucketQuantile estimates the q-quantile from cumulative bucket counts,
+// interpolating linearly within a bucket like PromQL's histogram_quantile
+func bucketQuantile(q float64, buckets map[float64]uint64, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+	bounds := make([]float64, 0, len(buckets))
+	for b := range buckets {
+		bounds = append(bounds, b)
+	}
+	sort.Float64s(bounds)
+
+	rank := q * float64(total)
+	lower, prev := 0.0, uint64(0)
+	for _, upper := range bounds {
+		count := buckets[upper]
+		if float64(count) >= rank {
+			if count == prev {
+				return upper
+			}
+			return lower + (upper-lower)*(rank-float64(prev))/float64(count-prev)
+		}
+		lower, prev = upper, count
+	}
+	// Rank falls in the implicit +Inf bucket: report the highest finite bound
+	// like histogram_quantile does
+	return bounds[len(bounds)-1]
+}
+
+// collectMetrics snapshots every series c currently exposes
+func collectMetrics(c prometheus.Collector) []*dto.Metric {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+
+	var out []*dto.Metric
+	for m := range ch {
+		var pb dto.Metric
+		if err := m.Write(&pb); err == nil {
+			out = append(out, &pb)
+		}
+	}
+	return out
+}
+
+// counterValue is the current value of a plain counter
+func counterValue(c prometheus.Counter) float64 {
+	var pb dto.Metric
+	c.Write(&pb)
+	return pb.GetCounter().GetValue()
+}
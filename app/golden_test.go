@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestGoldenSignals(t *testing.T) {
+	app := startApp(t, "FAILURE_RATE=0.5", "MAX_CONCURRENT_REQUESTS=50")
+
+	app.getConcurrently(20, "/work")
+	resp, body := app.get("/admin/golden")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	var g goldenSignals
+	if err := json.Unmarshal([]byte(body), &g); err != nil {
+		t.Fatalf("bad JSON %q: %v", body, err)
+	}
+
+	if g.Latency.P50 <= 0 || g.Latency.P50 > g.Latency.P95 || g.Latency.P95 > g.Latency.P99 {
+		t.Errorf("latency = %+v, want 0 < p50 <= p95 <= p99", g.Latency)
+	}
+	if g.Traffic.Requests != 20 || g.Traffic.RequestsPerSecond <= 0 {
+		t.Errorf("traffic = %+v, want 20 requests at a positive rate", g.Traffic)
+	}
+	if g.Errors.Errors == 0 || g.Errors.Ratio <= 0 || g.Errors.Ratio >= 1 {
+		t.Errorf("errors = %+v, want some but not all 5xx", g.Errors)
+	}
+	// The golden request itself is in flight
+	if g.Saturation.InFlight < 1 || g.Saturation.Limit != 50 ||
+		g.Saturation.Ratio == nil || *g.Saturation.Ratio <= 0 {
+		t.Errorf("saturation = %+v, want a non-zero ratio against the limit of 50", g.Saturation)
+	}
+}
+
+func TestGoldenErrorsWithoutStatusLabel(t *testing.T) {
+	app := startApp(t, "FAILURE_RATE=0.5", "DROP_REQUEST_LABELS=status")
+
+	app.getConcurrently(20, "/work")
+	_, body := app.get("/admin/golden")
+	var g goldenSignals
+	if err := json.Unmarshal([]byte(body), &g); err != nil {
+		t.Fatalf("bad JSON %q: %v", body, err)
+	}
+	if g.Traffic.Requests != 20 {
+		t.Errorf("traffic = %+v, want 20 requests", g.Traffic)
+	}
+	if g.Errors.Errors == 0 || g.Errors.Errors >= 20 || g.Errors.Ratio != float64(g.Errors.Errors)/20 {
+		t.Errorf("errors = %+v with status dropped, want some but not all of the 20 as 5xx", g.Errors)
+	}
+}
+
+func TestGoldenSaturationWithoutLimit(t *testing.T) {
+	app := startApp(t)
+
+	_, body := app.get("/admin/golden")
+	var g goldenSignals
+	if err := json.Unmarshal([]byte(body), &g); err != nil {
+		t.Fatalf("bad JSON %q: %v", body, err)
+	}
+	if g.Saturation.Ratio != nil {
+		t.Errorf("saturation ratio = %v with no limit, want null", *g.Saturation.Ratio)
+	}
+}
+
+func TestBucketQuantile(t *testing.T) {
+	// 10 observations in (0, 0.1], 10 more in (0.1, 1]
+	buckets := map[float64]uint64{0.1: 10, 1: 20}
+	for _, tc := range []struct{ q, want float64 }{
+		{0.25, 0.05},
+		{0.5, 0.1},
+		{0.75, 0.55},
+	} {
+		if got := bucketQuantile(tc.q, buckets, 20); got != tc.want {
+			t.Errorf("bucketQuantile(%v) = %v, want %v", tc.q, got, tc.want)
+		}
+	}
+	if got := bucketQuantile(0.5, buckets, 0); got != 0 {
+		t.Errorf("bucketQuantile with no observations = %v, want 0", got)
+	}
+}
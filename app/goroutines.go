@@ -0,0 +1,48 @@
+package main
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var goroutineGrowthSuspected = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "goroutine_growth_suspected",
+		Help: "1 if the goroutine count grew steadily across the whole GOROUTINE_LEAK_WINDOW, a likely leak",
+	},
+)
+
+// Samples taken per window; growth has to hold across all of them
+const goroutineSamples = 6
+
+// watchGoroutines samples the goroutine count and flags a suspected leak when
+// it never drops during a full window and grows by at least minGrowth.
+// Normal traffic goes up and down; a leak only goes up.
+func watchGoroutines(window time.Duration, minGrowth int) {
+	var samples []int
+	backgroundPool.every(window/goroutineSamples, func() {
+		_, span := startBackground("goroutine_watch")
+		samples = append(samples, runtime.NumGoroutine())
+		span.SetAttributes(attribute.Int("goroutine.count", samples[len(samples)-1]))
+		span.End()
+		if len(samples) > goroutineSamples+1 {
+			samples = samples[1:]
+		}
+		if len(samples) <= goroutineSamples {
+			return
+		}
+
+		suspected := samples[len(samples)-1]-samples[0] >= minGrowth
+		for i := 1; i < len(samples) && suspected; i++ {
+			suspected = samples[i] >= samples[i-1]
+		}
+		if suspected {
+			goroutineGrowthSuspected.Set(1)
+		} else {
+			goroutineGrowthSuspected.Set(0)
+		}
+	})
+}
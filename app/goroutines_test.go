@@ -0,0 +1,33 @@
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestGoroutineGrowthSuspected(t *testing.T) {
+	app := startApp(t, "GOROUTINE_LEAK_WINDOW=600ms", "GOROUTINE_LEAK_MIN_GROWTH=10")
+
+	if v := app.metricValue("goroutine_growth_suspected"); v != 0 {
+		t.Fatalf("goroutine_growth_suspected = %v before any leak, want 0", v)
+	}
+
+	// Each connection left open without a request parks a server goroutine
+	// until the header timeout, so opening them steadily leaks goroutines
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		for range 3 {
+			conn, err := net.Dial("tcp", app.addr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+		}
+		time.Sleep(50 * time.Millisecond)
+		if v, _ := seriesValue(app.scrape(), "goroutine_growth_suspected"); v == 1 {
+			return
+		}
+	}
+	t.Error("goroutine_growth_suspected never flipped to 1 while goroutines leaked")
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	otelcodes "go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Simulated downstream gRPC service called from /work
+const (
+	grpcDepService = "inventory.v1.InventoryService"
+	grpcDepMethod  = "GetStock"
+	grpcDepPeer    = "inventory"
+)
+
+// callGRPCDependency pretends to make a unary gRPC call and records a client
+// span shaped the way otelgrpc would, including rpc.grpc.status_code. It
+// returns a gRPC status error when the simulated call fails.
+func callGRPCDependency(ctx context.Context) error {
+	_, span := startClient(ctx, grpcDepService+"/"+grpcDepMethod,
+		trace.WithAttributes(
+			semconv.RPCSystemGRPC,
+			semconv.RPCService(grpcDepService),
+			semconv.RPCMethod(grpcDepMethod),
+			semconv.PeerService(grpcDepPeer),
+		),
+	)
+	defer span.End()
+
+	var err error
+	select {
+	case <-time.After(time.Duration(rand.Intn(50)) * time.Millisecond):
+		if rand.Float64() < cfg.GRPCDepErrorRate {
+			err = status.Error(cfg.GRPCDepErrorCode, "simulated dependency failure")
+		}
+	case <-ctx.Done():
+		// A real client gives up with DEADLINE_EXCEEDED or CANCELLED
+		err = status.FromContextError(ctx.Err()).Err()
+	}
+	code := status.Code(err)
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(code)))
+	if code == codes.OK {
+		return nil
+	}
+
+	span.RecordError(err)
+	span.SetStatus(otelcodes.Error, code.String())
+	return err
+}
+
+// parseGRPCCode accepts either a code name ("UNAVAILABLE") or its number ("14")
+func parseGRPCCode(s string) (codes.Code, error) {
+	if n, err := strconv.ParseUint(s, 10, 32); err == nil {
+		if n > uint64(codes.Unauthenticated) {
+			return 0, fmt.Errorf("unknown gRPC status code %d", n)
+		}
+		return codes.Code(n), nil
+	}
+	var c codes.Code
+	if err := c.UnmarshalJSON([]byte(fmt.Sprintf("%q", strings.ToUpper(s)))); err != nil {
+		return 0, err
+	}
+	return c, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestGRPCDependencyForcedCode(t *testing.T) {
+	app := startApp(t,
+		"GRPC_DEP_ENABLED=true",
+		"GRPC_DEP_ERROR_RATE=1",
+		"GRPC_DEP_ERROR_CODE=NOT_FOUND",
+	)
+
+	if resp, _ := app.get("/work"); resp.StatusCode != http.StatusBadGateway {
+		t.Fatalf("status = %d, want 502", resp.StatusCode)
+	}
+	span := app.spansNamed(grpcDepService+"/"+grpcDepMethod, 1)[0]
+	if v := span.attr("rpc.grpc.status_code"); v != float64(codes.NotFound) {
+		t.Errorf("rpc.grpc.status_code = %v, want %d", v, codes.NotFound)
+	}
+	if v := span.attr("rpc.system"); v != "grpc" {
+		t.Errorf("rpc.system = %v, want grpc", v)
+	}
+	if span.Status.Code != "Error" || span.Status.Description != "NotFound" {
+		t.Errorf("status = %+v, want Error/NotFound", span.Status)
+	}
+}
+
+func TestParseGRPCCode(t *testing.T) {
+	for in, want := range map[string]codes.Code{
+		"UNAVAILABLE": codes.Unavailable,
+		"not_found":   codes.NotFound,
+		"14":          codes.Unavailable,
+		"0":           codes.OK,
+	} {
+		got, err := parseGRPCCode(in)
+		if err != nil || got != want {
+			t.Errorf("parseGRPCCode(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"17", "BOGUS", "-1"} {
+		if _, err := parseGRPCCode(in); err == nil {
+			t.Errorf("parseGRPCCode(%q) succeeded, want an error", in)
+		}
+	}
+}
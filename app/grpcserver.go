@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// Route name the gRPC service's failures and logs are keyed by
+const grpcRoute = "grpc"
+
+// The served service is written out by hand rather than generated from a
+// .proto: one unary method taking and returning a google.protobuf.StringValue,
+// so any client can call it with grpcurl and the well-known types.
+var workServiceDesc = grpc.ServiceDesc{
+	ServiceName: "sandbox.v1.WorkService",
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "DoWork",
+		Handler:    doWorkHandler,
+	}},
+	Metadata: "sandbox/v1/work.proto",
+}
+
+func doWorkHandler(_ any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(wrapperspb.StringValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return doWork(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/sandbox.v1.WorkService/DoWork"}
+	return interceptor(ctx, in, info, func(ctx context.Context, req any) (any, error) {
+		return doWork(ctx, req.(*wrapperspb.StringValue))
+	})
+}
+
+// doWork is /work's gRPC counterpart: random latency, failing at the
+// "grpc" route's failure rate
+func doWork(ctx context.Context, in *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+	ctx = context.WithValue(ctx, routeKey{}, grpcRoute)
+	span := trace.SpanFromContext(ctx)
+	log := requestLogger(ctx)
+
+	latency := time.Duration(rand.Intn(200)) * time.Millisecond
+	if err := sleepCtx(ctx, latency); err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+	span.SetAttributes(
+		attribute.String("work.input", in.GetValue()),
+		attribute.Int64("work.latency_ms", latency.Milliseconds()),
+	)
+
+	if rand.Float64() < effectiveFailureRate(grpcRoute) {
+		log.Error("grpc request failed", "latency_ms", latency.Milliseconds())
+		return nil, status.Error(codes.Unavailable, "simulated failure")
+	}
+	log.Info("grpc request succeeded", "latency_ms", latency.Milliseconds())
+	return wrapperspb.String("Work completed: " + in.GetValue()), nil
+}
+
+// startGRPCServer serves the work service on addr. otelgrpc's stats handler
+// records the server spans and the rpc.server.* metrics through the global
+// providers, extracting the caller's context with the inbound propagator.
+func startGRPCServer(addr string) (*grpc.Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	srv := grpc.NewServer(grpc.StatsHandler(otelgrpc.NewServerHandler(
+		otelgrpc.WithPropagators(inboundPropagator),
+	)))
+	srv.RegisterService(&workServiceDesc, nil)
+	logger.Info("starting grpc server", "addr", ln.Addr().String())
+	go func() {
+		if err := srv.Serve(ln); err != nil {
+			logger.Error("grpc server failed", "error", err)
+		}
+	}()
+	return srv, nil
+}
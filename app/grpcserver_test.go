@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestGRPCServerSpan(t *testing.T) {
+	app := startApp(t, "GRPC_LISTEN_ADDR=127.0.0.1:0", "OTEL_METRIC_EXPORT_INTERVAL=200")
+	addr, _ := app.logsWithMsg("starting grpc server")[0]["addr"].(string)
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	out := new(wrapperspb.StringValue)
+	if err := conn.Invoke(ctx, "/sandbox.v1.WorkService/DoWork", wrapperspb.String("hello"), out); err != nil {
+		t.Fatalf("DoWork: %v", err)
+	}
+	if want := "Work completed: hello"; out.GetValue() != want {
+		t.Errorf("reply = %q, want %q", out.GetValue(), want)
+	}
+
+	span := app.spansNamed("sandbox.v1.WorkService/DoWork", 1)[0]
+	if span.SpanKind != spanKindServer {
+		t.Errorf("span kind = %d, want server", span.SpanKind)
+	}
+	for key, want := range map[string]any{
+		"rpc.system":  "grpc",
+		"rpc.service": "sandbox.v1.WorkService",
+		"rpc.method":  "DoWork",
+		"work.input":  "hello",
+	} {
+		if got := span.attr(key); got != want {
+			t.Errorf("%s = %v, want %v", key, got, want)
+		}
+	}
+
+	app.waitFor("rpc.server metrics", func() bool {
+		for name := range app.collector.metricScopes() {
+			if strings.HasPrefix(name, "rpc.server.") {
+				return true
+			}
+		}
+		return false
+	})
+}
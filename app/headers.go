@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// withHeaderCapture records the allowlisted request and response headers on
+// the server span as http.request.header.<name> and
+// http.response.header.<name>, the semantic-convention keys: lowercase names,
+// every value of the header as a string array. Only listed headers are
+// recorded, since headers easily carry credentials.
+func withHeaderCapture(next http.Handler) http.Handler {
+	if len(cfg.CaptureRequestHeaders) == 0 && len(cfg.CaptureResponseHeaders) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span := trace.SpanFromContext(r.Context())
+		span.SetAttributes(headerAttributes("http.request.header.", r.Header, cfg.CaptureRequestHeaders)...)
+		next.ServeHTTP(w, r)
+		span.SetAttributes(headerAttributes("http.response.header.", w.Header(), cfg.CaptureResponseHeaders)...)
+	})
+}
+
+func headerAttributes(prefix string, h http.Header, names []string) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	for _, name := range names {
+		if values := h.Values(name); len(values) > 0 {
+			attrs = append(attrs, attribute.StringSlice(prefix+strings.ToLower(name), values))
+		}
+	}
+	return attrs
+}
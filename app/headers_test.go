@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestHeaderCaptureSemconvKeys(t *testing.T) {
+	app := startApp(t,
+		"CAPTURE_REQUEST_HEADERS=X-Tenant",
+		"CAPTURE_RESPONSE_HEADERS=Content-Type",
+	)
+	req, err := http.NewRequest(http.MethodGet, app.url+"/admin/golden", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("X-Tenant", "acme")
+	req.Header.Add("X-Tenant", "globex")
+	req.Header.Set("X-Other", "secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	span := app.spansNamed("admin_golden", 1)[0]
+	if got := span.attr("http.request.header.x-tenant"); !slices.Equal(toStrings(got), []string{"acme", "globex"}) {
+		t.Errorf("http.request.header.x-tenant = %v, want [acme globex]", got)
+	}
+	if got := span.attr("http.response.header.content-type"); !slices.Equal(toStrings(got), []string{"application/json"}) {
+		t.Errorf("http.response.header.content-type = %v, want [application/json]", got)
+	}
+	if got := span.attr("http.request.header.x-other"); got != nil {
+		t.Errorf("unlisted header recorded as %v", got)
+	}
+}
+
+func TestHeaderCaptureOffByDefault(t *testing.T) {
+	app := startApp(t)
+	app.get("/admin/golden", "X-Tenant", "acme")
+	for _, kv := range app.spansNamed("admin_golden", 1)[0].Attributes {
+		if kv.Key == "http.request.header.x-tenant" || kv.Key == "http.response.header.content-type" {
+			t.Errorf("%s recorded without an allowlist", kv.Key)
+		}
+	}
+}
+
+// toStrings converts a string array attribute decoded from JSON
+func toStrings(v any) []string {
+	var out []string
+	values, _ := v.([]any)
+	for _, s := range values {
+		str, _ := s.(string)
+		out = append(out, str)
+	}
+	return out
+}
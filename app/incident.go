@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// A simulated incident starts at full intensity and decays exponentially,
+// reaching (almost) zero by the end of its duration, so dashboards show a
+// realistic spike-and-recovery shape rather than a step.
+var incident struct {
+	sync.Mutex
+	start    time.Time
+	duration time.Duration
+}
+
+// Decay rate over the incident's duration; exp(-5) leaves under 1% at the end
+const incidentDecay = 5.0
+
+// incidentIntensity is 1 when an incident starts and decays towards 0
+func incidentIntensity() float64 {
+	incident.Lock()
+	defer incident.Unlock()
+	if incident.start.IsZero() {
+		return 0
+	}
+	elapsed := time.Since(incident.start)
+	if elapsed >= incident.duration {
+		return 0
+	}
+	return math.Exp(-incidentDecay * elapsed.Seconds() / incident.duration.Seconds())
+}
+
+// effectiveFailureRate blends the route's baseline towards the incident
+// peak, jumps to the peak during a scheduled error anomaly, plus whatever a
+// recent db failure adds
+func effectiveFailureRate(route string) float64 {
+	base := baseFailureRate(route)
+	i := incidentIntensity()
+	rate := base + (math.Max(cfg.IncidentFailureRate, base)-base)*i
+	if anomalyActive("errors") {
+		rate = math.Max(rate, cfg.IncidentFailureRate)
+	}
+	return math.Min(1, rate+cascadeBoost())
+}
+
+func incidentLatency() time.Duration {
+	return time.Duration(float64(cfg.IncidentLatency) * incidentIntensity())
+}
+
+type incidentStatus struct {
+	Active               bool    `json:"active"`
+	Intensity            float64 `json:"intensity"`
+	EffectiveFailureRate float64 `json:"effective_failure_rate"`
+	ExtraLatencyMs       int64   `json:"extra_latency_ms"`
+	CascadeBoost         float64 `json:"cascade_boost"`
+}
+
+// incidentHandler starts an incident on POST (optionally ?duration=2m) and
+// reports the current state on GET
+func incidentHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		d := cfg.IncidentDuration
+		if raw := r.URL.Query().Get("duration"); raw != "" {
+			v, err := time.ParseDuration(raw)
+			if err != nil || v <= 0 {
+				http.Error(w, "duration must be a positive Go duration", http.StatusBadRequest)
+				return
+			}
+			d = v
+		}
+		incident.Lock()
+		incident.start, incident.duration = time.Now(), d
+		incident.Unlock()
+		logger.Warn("simulated incident started", "duration", d.String())
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	i := incidentIntensity()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(incidentStatus{
+		Active:               i > 0,
+		Intensity:            i,
+		EffectiveFailureRate: effectiveFailureRate("work"),
+		ExtraLatencyMs:       incidentLatency().Milliseconds(),
+		CascadeBoost:         cascadeBoost(),
+	})
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIncidentDecaysToBaseline(t *testing.T) {
+	app := startApp(t, "FAILURE_RATE=0.1", "INCIDENT_FAILURE_RATE=0.9")
+
+	status := func(method string) incidentStatus {
+		t.Helper()
+		resp, body := app.do(method, "/admin/incident?duration=1s", nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: status = %d, want 200", method, resp.StatusCode)
+		}
+		var s incidentStatus
+		if err := json.Unmarshal([]byte(body), &s); err != nil {
+			t.Fatalf("bad JSON %q: %v", body, err)
+		}
+		return s
+	}
+
+	if s := status(http.MethodGet); s.Active || s.EffectiveFailureRate != 0.1 {
+		t.Fatalf("before the incident: %+v, want inactive at the 0.1 baseline", s)
+	}
+	s := status(http.MethodPost)
+	if !s.Active || s.EffectiveFailureRate < 0.85 {
+		t.Fatalf("incident start: %+v, want active near the 0.9 peak", s)
+	}
+	prev := s.EffectiveFailureRate
+	for range 4 {
+		time.Sleep(200 * time.Millisecond)
+		s := status(http.MethodGet)
+		if s.EffectiveFailureRate >= prev || s.EffectiveFailureRate < 0.1 {
+			t.Errorf("failure rate went from %v to %v, want a decay towards 0.1", prev, s.EffectiveFailureRate)
+		}
+		prev = s.EffectiveFailureRate
+	}
+	time.Sleep(300 * time.Millisecond)
+	if s := status(http.MethodGet); s.Active || s.EffectiveFailureRate != 0.1 {
+		t.Errorf("after the incident: %+v, want inactive at the 0.1 baseline", s)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Marker the collector's tail sampler keys on to keep a whole trace
+var keepAttribute = attribute.Bool("sampling.keep", true)
+
+// keepProcessor sits in front of an exporting span processor and marks spans
+// that ended with one of the KEEP_ATTRIBUTES, which head sampling couldn't
+// know about when the trace started. The ended span is handed on with the
+// marker added; the spans of the same trace that are still open (the server
+// span, usually) get it set directly, so the request's root says it too.
+type keepProcessor struct {
+	sdktrace.SpanProcessor
+}
+
+// withKeepMarking wraps p, or returns it unchanged without KEEP_ATTRIBUTES
+func withKeepMarking(p sdktrace.SpanProcessor) sdktrace.SpanProcessor {
+	if len(cfg.KeepAttributes) == 0 {
+		return p
+	}
+	return keepProcessor{p}
+}
+
+func (p keepProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	attrs := s.Attributes()
+	if !slices.Contains(attrs, keepAttribute) && keepWorthy(attrs) {
+		activeSpans.markTrace(s.SpanContext().TraceID(), keepAttribute)
+		s = scrubbedSpan{s, append(slices.Clone(attrs), keepAttribute), s.Events()}
+	}
+	p.SpanProcessor.OnEnd(s)
+}
+
+// keepWorthy reports whether attrs hold any KEEP_ATTRIBUTES key=value pair.
+// Values are compared in their string form, so app.debug=true matches a
+// bool attribute.
+func keepWorthy(attrs []attribute.KeyValue) bool {
+	for _, kv := range attrs {
+		if slices.Contains(cfg.KeepAttributes[string(kv.Key)], kv.Value.Emit()) {
+			return true
+		}
+	}
+	return false
+}
+
+// markTrace sets kv on every span of the trace that hasn't ended yet
+func (t *spanTracker) markTrace(id trace.TraceID, kv attribute.KeyValue) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, ts := range t.active {
+		if ts.span.SpanContext().TraceID() == id {
+			ts.span.SetAttributes(kv)
+		}
+	}
+}
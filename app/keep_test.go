@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestKeepAttributesMarksSpanAndOpenAncestors(t *testing.T) {
+	app := startApp(t, "KEEP_ATTRIBUTES=deep.level=3")
+	app.get("/deep?depth=5")
+	app.get("/deep?depth=2")
+
+	levels := app.spansNamed("level", 7)
+	var matching string
+	for _, s := range app.spansNamed("deep", 2) {
+		deep := s.attr("deep.depth") == float64(5)
+		if deep {
+			matching = s.SpanContext.TraceID
+		}
+		if kept := s.attr("sampling.keep") == true; kept != deep {
+			t.Errorf("server span of depth %v: sampling.keep = %v, want %v", s.attr("deep.depth"), kept, deep)
+		}
+	}
+	for _, s := range levels {
+		// Levels 4 and 5 had ended by the time level 3 did, so only the
+		// matching span and the ones still open around it are marked
+		level := s.attr("deep.level").(float64)
+		want := s.SpanContext.TraceID == matching && level <= 3
+		if kept := s.attr("sampling.keep") == true; kept != want {
+			t.Errorf("level %v (depth-5 trace %v): sampling.keep = %v, want %v", level, s.SpanContext.TraceID == matching, kept, want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Upper bound on records a single /admin/log-burst request may emit
+const maxLogBurst = 10000
+
+type logBurstResult struct {
+	Emitted int    `json:"emitted"`
+	Level   string `json:"level"`
+}
+
+// logBurstHandler emits count structured records at the given level, for
+// load testing the log pipeline. Records carry the request's trace context.
+func logBurstHandler(w http.ResponseWriter, r *http.Request) {
+	count := 100
+	if raw := r.URL.Query().Get("count"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 1 || v > maxLogBurst {
+			http.Error(w, fmt.Sprintf("count must be between 1 and %d", maxLogBurst), http.StatusBadRequest)
+			return
+		}
+		count = v
+	}
+	level := slog.LevelInfo
+	if raw := r.URL.Query().Get("level"); raw != "" {
+		if err := level.UnmarshalText([]byte(raw)); err != nil {
+			http.Error(w, "level must be one of debug, info, warn, error", http.StatusBadRequest)
+			return
+		}
+	}
+
+	ctx := r.Context()
+	log := logger
+	if sc := trace.SpanFromContext(ctx).SpanContext(); sc.IsValid() {
+		log = log.With("trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
+	}
+	emitted := 0
+	for ; emitted < count && ctx.Err() == nil; emitted++ {
+		log.Log(context.Background(), level, "log burst record", "seq", emitted, "of", count)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(logBurstResult{Emitted: emitted, Level: level.String()})
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestLogBurstEmitsCount(t *testing.T) {
+	app := startApp(t)
+
+	resp, body := app.get("/admin/log-burst?count=25&level=warn")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if body != `{"emitted":25,"level":"WARN"}`+"\n" {
+		t.Errorf("body = %q", body)
+	}
+
+	traceID := app.spansNamed("admin_log_burst", 1)[0].SpanContext.TraceID
+	var records int
+	for _, rec := range app.logs() {
+		if rec["msg"] != "log burst record" {
+			continue
+		}
+		records++
+		if rec["level"] != "WARN" || rec["trace_id"] != traceID || rec["of"] != float64(25) {
+			t.Errorf("record = %v, want WARN in trace %s", rec, traceID)
+		}
+	}
+	if records != 25 {
+		t.Errorf("got %d records, want 25", records)
+	}
+}
+
+func TestLogBurstRejectsBadCount(t *testing.T) {
+	app := startApp(t)
+
+	for _, q := range []string{"count=0", "count=10001", "level=loud"} {
+		if resp, _ := app.get("/admin/log-burst?" + q); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", q, resp.StatusCode)
+		}
+	}
+}
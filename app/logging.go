@@ -0,0 +1,64 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var logWriteErrors = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "log_write_errors_total",
+		Help: "Structured log records that could not be written to stdout",
+	},
+)
+
+// resilientWriter keeps logging from taking the app down when stdout goes
+// away (e.g. a log shipper dies and leaves us writing into a broken pipe).
+// Failed writes are counted and retried on the fallback writer, if any, and
+// never reported back to the logger.
+type resilientWriter struct {
+	mu       sync.Mutex
+	primary  io.Writer
+	fallback io.Writer
+}
+
+var logOutput = &resilientWriter{primary: os.Stdout}
+
+func (w *resilientWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, err := w.primary.Write(p); err != nil {
+		logWriteErrors.Inc()
+		if w.fallback != nil {
+			w.fallback.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (w *resilientWriter) setFallback(fallback io.Writer) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.fallback = fallback
+}
+
+// openLogFallback resolves LOG_FALLBACK: "stderr", a file path, or empty to
+// just drop records stdout can't take
+func openLogFallback(target string) io.Writer {
+	switch target {
+	case "":
+		return nil
+	case "stderr":
+		return os.Stderr
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		log.Fatalf("failed to open log fallback %s: %v", target, err)
+	}
+	return f
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestResilientWriterFallsBack(t *testing.T) {
+	var fallback bytes.Buffer
+	w := &resilientWriter{primary: failingWriter{}, fallback: &fallback}
+	before := testutil.ToFloat64(logWriteErrors)
+
+	n, err := w.Write([]byte("record\n"))
+	if n != len("record\n") || err != nil {
+		t.Errorf("Write = %d, %v; want the full length and no error", n, err)
+	}
+	if fallback.String() != "record\n" {
+		t.Errorf("fallback got %q", fallback.String())
+	}
+	if got := testutil.ToFloat64(logWriteErrors) - before; got != 1 {
+		t.Errorf("log_write_errors_total grew by %v, want 1", got)
+	}
+}
+
+func TestServesWithStdoutClosed(t *testing.T) {
+	app := startApp(t, "LOG_FALLBACK=stderr")
+	app.stdout.Close()
+
+	for range 3 {
+		if resp, _ := app.get("/fanout"); resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d with stdout closed, want 200", resp.StatusCode)
+		}
+	}
+	if v := app.metricValue("log_write_errors_total"); v == 0 {
+		t.Error("log_write_errors_total = 0, want the failed stdout writes counted")
+	}
+	// The fallback is stderr, which the harness still reads
+	app.logsWithMsg("fanout completed")
+}
@@ -2,116 +2,531 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
 	"log/slog"
 	"math/rand"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
-    "github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
-	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
 	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
 )
 
-var logger *slog.Logger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+// Set once SIGTERM is received; /readyz reports 503 from then on
+var lameDuck atomic.Bool
 
-// Prometheus histogram to carry exemplars
-var reqDuration = prometheus.NewHistogramVec(
-    prometheus.HistogramOpts{
-        Name:    "http_request_duration_seconds",
-        Help:    "HTTP request duration seconds",
-        Buckets: prometheus.DefBuckets,
-    },
-    []string{"method", "status"},
-)
+// Structured logs to stdout. Levels are filtered by debugLevelHandler, so
+// the JSON handler itself lets everything through.
+var jsonLogHandler = slog.NewJSONHandler(logOutput, &slog.HandlerOptions{Level: slog.LevelDebug})
+
+var logger = newLogger(jsonLogHandler)
+
+func newLogger(h slog.Handler) *slog.Logger {
+	return slog.New(correlationHandler{Handler: debugLevelHandler{h}})
+}
+
+var reqDurationBuckets = prometheus.DefBuckets
+
+// Prometheus histogram to carry exemplars. Built in main, once we know
+// whether it should also be a native histogram.
+var reqDuration *prometheus.HistogramVec
+
+// newReqDuration builds the request histogram. With native set it also keeps
+// a native (sparse, exponential) histogram, which Prometheus only receives
+// when it scrapes the protobuf format; text scrapes still see the classic
+// buckets.
+func newReqDuration(native bool) *prometheus.HistogramVec {
+	opts := prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request duration seconds",
+		Buckets: reqDurationBuckets,
+	}
+	if native {
+		opts.NativeHistogramBucketFactor = 1.1
+		opts.NativeHistogramMaxBucketNumber = 100
+		opts.NativeHistogramMinResetDuration = time.Hour
+	}
+	return prometheus.NewHistogramVec(opts, reqLabelNames())
+}
+
+// Optional summary recorded next to the histogram, for comparing against
+// legacy summary-based dashboards. Nil unless REQUEST_SUMMARY_ENABLED.
+var reqSummary *prometheus.SummaryVec
 
 func main() {
+	cfg = loadConfig()
+
+	// Set by deferred cleanup that failed; registered first so it runs after
+	// every other defer, the log writer's included
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
+
+	// A closed stdout must not kill the process: without this, writing to a
+	// broken pipe on fd 1 raises SIGPIPE and the runtime exits
+	signal.Ignore(syscall.SIGPIPE)
+	logOutput.setFallback(openLogFallback(cfg.LogFallback))
+	if cfg.LogAsyncBuffer > 0 {
+		asyncLogs := newAsyncLogWriter(logOutput, cfg.LogAsyncBuffer, cfg.LogFlushInterval)
+		defer asyncLogs.close()
+		jsonLogHandler = slog.NewJSONHandler(asyncLogs, &slog.HandlerOptions{Level: slog.LevelDebug})
+		logger = newLogger(jsonLogHandler)
+	}
+	logger.Info("starting sample-app", "version", serviceVersion, "commit", buildCommit(), "config", cfg)
+
+	backgroundPool = newWorkerPool(cfg.BackgroundWorkers)
+
 	// Initialize OpenTelemetry
 	ctx := context.Background()
 	shutdown := initOTel(ctx)
-	defer shutdown(ctx)
+	defer func() {
+		// Telemetry lost in the final flush is a failed shutdown, whatever
+		// the server itself did
+		if err := shutdown(); err != nil {
+			logger.Error("telemetry shutdown incomplete", "error", err)
+			exitCode = 1
+		}
+	}()
+	if err := registerMeters(); err != nil {
+		log.Fatalf("failed to register OTel instruments: %v", err)
+	}
+	if cfg.MaxSpanDuration > 0 {
+		go activeSpans.watch(cfg.MaxSpanDuration)
+	}
+	if cfg.DBPoolSize > 0 {
+		initDBPool(cfg.DBPoolSize)
+	}
+	if cfg.CollectorHealthInterval > 0 {
+		go pollCollectors(cfg.CollectorHealthInterval)
+	}
+	if cfg.ApdexTarget > 0 {
+		go publishApdex(cfg.ApdexWindow)
+	}
+	if cfg.GoroutineLeakWindow > 0 {
+		go watchGoroutines(cfg.GoroutineLeakWindow, cfg.GoroutineLeakMinGrowth)
+	}
+	if cfg.MQEnabled {
+		go runMessageQueue()
+	}
+	if cfg.CacheEvictionInterval > 0 {
+		go evictCache(cfg.CacheEvictionInterval)
+	}
+	if cfg.DownstreamConnectDelay > 0 {
+		downstreamClient.Transport = slowConnectTransport(cfg.DownstreamConnectDelay)
+	}
 
 	// Setup HTTP handlers with automatic tracing
-	http.Handle("/healthz", otelhttp.NewHandler(http.HandlerFunc(healthzHandler), "healthz"))
-	http.Handle("/work", otelhttp.NewHandler(http.HandlerFunc(workHandler), "work"))
+	handle("/healthz", "healthz", http.HandlerFunc(healthzHandler))
+	handle("/readyz", "readyz", http.HandlerFunc(readyzHandler))
+	handle("/work", "work", http.HandlerFunc(workHandler))
+	handle("/fanout", "fanout", http.HandlerFunc(fanoutHandler))
+	handle("/echo-body", "echo_body", http.HandlerFunc(echoBodyHandler))
+	handle("/paged", "paged", http.HandlerFunc(pagedHandler))
+	handle("/deep", "deep", http.HandlerFunc(deepHandler))
+	handle("/batch", "batch", http.HandlerFunc(batchHandler))
+	handle("/range", "range", http.HandlerFunc(rangeHandler))
+	handle("/admin/golden", "admin_golden", http.HandlerFunc(goldenHandler))
+	handle("/admin/incident", "admin_incident", http.HandlerFunc(incidentHandler))
+	handle("/admin/degrade", "admin_degrade", http.HandlerFunc(degradeHandler))
+	handle("/admin/simulate-collector-down", "admin_simulate_collector_down", http.HandlerFunc(simulateCollectorDownHandler))
+	handle("/admin/peak-trace", "admin_peak_trace", http.HandlerFunc(peakTraceHandler))
+	handle("/admin/sampling-stats", "admin_sampling_stats", http.HandlerFunc(samplingStatsHandler))
+	handle("/admin/log-burst", "admin_log_burst", http.HandlerFunc(logBurstHandler))
+	handle("/admin/propagation-test", "admin_propagation_test", http.HandlerFunc(propagationTestHandler))
+	handle("/admin/orphan-span", "admin_orphan_span", http.HandlerFunc(orphanSpanHandler))
+	handle("/admin/forced-trace", forcedTraceRoute, http.HandlerFunc(forcedTraceHandler))
+	handle("/admin/panic", "admin_panic", http.HandlerFunc(panicHandler))
+	handle("/admin/consistency", "admin_consistency", http.HandlerFunc(consistencyHandler))
+
+	// Left untraced, like /metrics, so it doesn't report on itself
+	http.HandleFunc("/admin/last-span-json", lastSpanJSONHandler)
 
-	// Register Prometheus metrics
-	prometheus.MustRegister(reqDuration)
-	http.Handle("/metrics", promhttp.HandlerFor(
-		prometheus.DefaultGatherer,
+	// Register Prometheus metrics. With DEPLOYMENT_ID or REGION set, every
+	// app series carries them as labels so dashboards can be split by
+	// release or region.
+	reg := prometheus.DefaultRegisterer
+	constLabels := prometheus.Labels{}
+	if cfg.DeploymentID != "" {
+		constLabels["deployment_id"] = cfg.DeploymentID
+	}
+	if cfg.Region != "" {
+		constLabels["region"] = cfg.Region
+	}
+	if len(constLabels) > 0 {
+		reg = prometheus.WrapRegistererWith(constLabels, reg)
+	}
+	reqDuration = registerOrReuse(reg, newReqDuration(cfg.NativeHistograms))
+	checkExemplarSupport(reqDuration)
+	if len(cfg.AnomalySchedule) > 0 {
+		registerCollectors(reg, anomalyGauges()...)
+	}
+	if rootSpans != nil {
+		registerCollectors(reg, rootSpans.gauges()...)
+	}
+	if cfg.MQEnabled {
+		registerCollectors(reg, consumerLag, messagesProcessed)
+	}
+	if cfg.ConfigMetrics {
+		registerCollectors(reg, configGauges()...)
+	}
+	if cfg.RampRate != 0 {
+		registerCollectors(reg, rampGauge)
+	}
+	if cfg.UTF8MetricNames {
+		utf8Duration = registerOrReuse(reg, newUTF8Duration())
+	}
+	registerCollectors(reg,
+		newBuildInfo(),
+		oversizedHeaderRejections,
+		serverTimeouts,
+		clientCancellations,
+		downstreamRetries,
+		retryBudgetExhausted,
+		authFailures,
+		authLatency,
+		pagesProcessed,
+		instrumentationOverhead,
+		coldStartSeconds,
+		logRecordsDropped,
+		panicsRecovered,
+		cacheEvictions,
+		batchProgress,
+		flagEvaluations,
+		adminActions,
+		rangeResponses,
+		downstreamRateLimited,
+		downstreamConnections,
+		logWriteErrors,
+		inFlight,
+		concurrencyRejections,
+		cacheHits,
+		cacheMisses,
+		longRunningSpans,
+		goroutineGrowthSuspected,
+		echoRequestSize,
+		echoResponseSize,
+		collectorReachableGauge,
+		poolBusyWorkers,
+		poolSize,
+		exemplarOverwrites,
+		exemplarEligible,
+		exemplarAttached,
+		exemplarObserverUnsupported,
+		startupFirstExport,
+		exportFailures,
+		apdexScore,
+		sloRequests,
+		sloGoodRequests,
+		errorBudgetBurnRateGauge,
+		exportBatchesWaiting,
+		exportBatchesInFlight,
+		requestsByCountry,
+		requestsByProtocol,
+	)
+	if cfg.RequestSummaryEnabled {
+		reqSummary = registerOrReuse(reg, prometheus.NewSummaryVec(
+			prometheus.SummaryOpts{
+				Name:       "http_request_duration_summary",
+				Help:       "HTTP request duration seconds, as a summary",
+				Objectives: cfg.RequestSummaryObjectives,
+			},
+			reqLabelNames(),
+		))
+	}
+	if len(cfg.GoRuntimeMetrics) > 0 {
+		registerGoRuntimeMetrics(cfg.GoRuntimeMetrics)
+	}
+	if cfg.CollectorHealthURL != "" {
+		registerCollectors(reg, collectorHealthyGauge)
+	}
+	if cfg.DBPoolSize > 0 {
+		registerCollectors(reg, dbPoolInUse, dbPoolIdle, dbPoolWait)
+	}
+	if cfg.SDKObservability {
+		registerCollectors(reg, bspCollector{})
+	}
+	if cfg.MetricsWarmup {
+		warmupRequestMetrics()
+	}
+	var gatherer prometheus.Gatherer = prometheus.DefaultGatherer
+	if cfg.TestDeterministic {
+		enableDeterministic()
+		gatherer = frozenGatherer{gatherer}
+	}
+	http.Handle(cfg.MetricsPath, promhttp.HandlerFor(
+		gatherer,
 		promhttp.HandlerOpts{
-			EnableOpenMetrics: true,
+			// Exemplars are only exposed in the OpenMetrics format
+			EnableOpenMetrics: cfg.EnableExemplars,
 		},
 	))
-	log.Println("Starting server on :8080")
-	log.Fatal(http.ListenAndServe(":8080", nil))
+
+	srv := &http.Server{
+		Addr:              cfg.ListenAddr,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+	if cfg.PathNormalization != "off" {
+		srv.Handler = normalizePaths(http.DefaultServeMux, cfg.PathNormalization)
+	}
+	if cfg.H2C {
+		// HTTP/2 without TLS, for clients using prior knowledge
+		srv.Protocols = new(http.Protocols)
+		srv.Protocols.SetHTTP1(true)
+		srv.Protocols.SetUnencryptedHTTP2(true)
+	}
+	var lc net.ListenConfig
+	if cfg.ReusePort {
+		lc.Control = reusePortControl
+	}
+	ln, err := lc.Listen(ctx, cfg.ListenNetwork, cfg.ListenAddr)
+	if err != nil {
+		log.Fatalf("failed to listen on %s/%s: %v", cfg.ListenNetwork, cfg.ListenAddr, err)
+	}
+	log.Printf("Starting server on %s/%s", cfg.ListenNetwork, ln.Addr())
+	go func() {
+		if err := srv.Serve(headerLimitListener{ln}); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("server failed: %v", err)
+		}
+	}()
+	if cfg.UpstreamRate > 0 {
+		go runUpstream(upstreamTargetURL(ln.Addr()))
+	}
+	var grpcSrv *grpc.Server
+	if cfg.GRPCListenAddr != "" {
+		grpcSrv, err = startGRPCServer(cfg.GRPCListenAddr)
+		if err != nil {
+			log.Fatalf("failed to listen on %s for grpc: %v", cfg.GRPCListenAddr, err)
+		}
+	}
+
+	// Wait for SIGTERM/SIGINT, then shut down gracefully
+	sigCtx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	<-sigCtx.Done()
+
+	// Lame duck: fail readiness so load balancers deregister us, but keep
+	// serving traffic normally until the window is over
+	lameDuck.Store(true)
+	log.Printf("Entering lame duck mode for %s", cfg.LameDuckDuration)
+	time.Sleep(cfg.LameDuckDuration)
+
+	log.Printf("Draining connections (timeout %s)", cfg.ShutdownTimeout)
+	drainCtx, cancel := context.WithTimeout(ctx, cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(drainCtx); err != nil {
+		log.Printf("server shutdown: %v", err)
+	}
+	if grpcSrv != nil {
+		grpcSrv.GracefulStop()
+	}
+
+	if cfg.MetricsDumpFile != "" {
+		if err := dumpMetrics(cfg.MetricsDumpFile); err != nil {
+			log.Printf("metrics dump: %v", err)
+		} else {
+			log.Printf("Wrote final metrics to %s", cfg.MetricsDumpFile)
+		}
+	}
 }
 
-func initOTel(ctx context.Context) func(context.Context) {
+func initOTel(ctx context.Context) func() error {
 	// Create resource (identifies this service)
-	res, err := resource.New(ctx,
-		resource.WithAttributes(
-			semconv.ServiceName("sample-app"),
-			semconv.ServiceVersion("1.0.0"),
-		),
-	)
+	attrs := []attribute.KeyValue{
+		semconv.ServiceName("sample-app"),
+		semconv.ServiceVersion(serviceVersion),
+		attribute.String("vcs.revision", buildCommit()),
+	}
+	if cfg.DeploymentID != "" {
+		attrs = append(attrs, attribute.String("deployment.id", cfg.DeploymentID))
+	}
+	if cfg.Region != "" {
+		attrs = append(attrs, semconv.CloudRegion(cfg.Region))
+	}
+	res, err := resource.New(ctx, resource.WithAttributes(attrs...))
 	if err != nil {
 		log.Fatalf("failed to create resource: %v", err)
 	}
 
-	// Get OTel Collector endpoint
-	otelEndpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
-	if otelEndpoint == "" {
-		otelEndpoint = "otel-collector:4317"
+	// Setup context propagation
+	inboundPropagator, err = buildPropagator(cfg.Propagators)
+	if err != nil {
+		log.Fatalf("invalid OTEL_PROPAGATORS: %v", err)
 	}
-
-	// Setup trace exporter
-	traceExporter, err := otlptracegrpc.New(ctx,
-		otlptracegrpc.WithInsecure(),
-		otlptracegrpc.WithEndpoint(otelEndpoint),
-	)
+	outboundPropagator, err = buildPropagator(cfg.OutboundPropagators)
 	if err != nil {
-		log.Fatalf("failed to create trace exporter: %v", err)
+		log.Fatalf("invalid OUTBOUND_PROPAGATORS: %v", err)
+	}
+	if cfg.DebugHeaderEnabled {
+		inboundPropagator = propagation.NewCompositeTextMapPropagator(inboundPropagator, debugPropagator{})
+		outboundPropagator = propagation.NewCompositeTextMapPropagator(outboundPropagator, debugPropagator{})
 	}
+	otel.SetTextMapPropagator(outboundPropagator)
 
-	// Setup trace provider
-	tracerProvider := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(traceExporter),
-		sdktrace.WithResource(res),
-	)
-	otel.SetTracerProvider(tracerProvider)
+	if cfg.ExportMaxConcurrency > 0 {
+		exportSlots = make(chan struct{}, cfg.ExportMaxConcurrency)
+	}
 
-	// Setup metric exporter
-	metricExporter, err := otlpmetricgrpc.New(ctx,
-		otlpmetricgrpc.WithInsecure(),
-		otlpmetricgrpc.WithEndpoint(otelEndpoint),
-	)
-	if err != nil {
-		log.Fatalf("failed to create metric exporter: %v", err)
+	// Each configured endpoint gets its own trace batcher or metric reader,
+	// so the same telemetry fans out to every destination
+	var traceOpts []sdktrace.TracerProviderOption
+	var metricOpts []sdkmetric.Option
+	for _, endpoint := range cfg.OTLPTraceEndpoints {
+		// Setup trace exporter
+		traceExporter, err := otlptracegrpc.New(ctx,
+			otlptracegrpc.WithInsecure(),
+			otlptracegrpc.WithEndpoint(endpoint),
+		)
+		if err != nil {
+			log.Fatalf("failed to create trace exporter for %s: %v", endpoint, err)
+		}
+		traceOpts = append(traceOpts, sdktrace.WithSpanProcessor(withKeepMarking(withScrubbing(
+			sdktrace.NewBatchSpanProcessor(exportLimiter{withClockSkew(firstExportRecorder{faultySpanExporter{traceExporter}})}),
+		))))
+	}
+	for _, endpoint := range cfg.OTLPMetricEndpoints {
+		// Setup metric exporter
+		metricExporter, err := otlpmetricgrpc.New(ctx,
+			otlpmetricgrpc.WithInsecure(),
+			otlpmetricgrpc.WithEndpoint(endpoint),
+		)
+		if err != nil {
+			log.Fatalf("failed to create metric exporter for %s: %v", endpoint, err)
+		}
+		metricOpts = append(metricOpts, sdkmetric.WithReader(sdkmetric.NewPeriodicReader(faultyMetricExporter{metricExporter})))
+	}
+
+	if cfg.TestDeterministic {
+		traceOpts = append(traceOpts, sdktrace.WithIDGenerator(&sequentialIDs{}))
+	}
+
+	sampler, ok := pathHashSamplerFromConfig()
+	if cfg.DebugHeaderEnabled {
+		// X-Debug requests are sampled on top of whatever the sampler keeps
+		if !ok {
+			sampler = builtinSamplerFromConfig()
+		}
+		sampler, ok = debugSampler{sampler}, true
+	}
+	if !ok {
+		sampler = builtinSamplerFromConfig()
+	}
+	// Counted for /admin/sampling-stats
+	traceOpts = append(traceOpts, sdktrace.WithSampler(countingSampler{sampler}))
+
+	if cfg.SpanJSONExport != "off" {
+		traceOpts = append(traceOpts, sdktrace.WithSpanProcessor(withKeepMarking(withScrubbing(
+			sdktrace.NewSimpleSpanProcessor(exportLimiter{withClockSkew(newSpanJSONExporter(cfg.SpanJSONExport))}),
+		))))
+	}
+
+	if len(cfg.DropRequestLabels) > 0 {
+		metricOpts = append(metricOpts, sdkmetric.WithView(dropRequestLabelsView()))
+	}
+
+	// The SDK's self-diagnostics are pulled through a manual reader on scrape
+	if cfg.SDKObservability {
+		metricOpts = append(metricOpts, sdkmetric.WithReader(sdkReader))
 	}
 
-	// Setup metric provider
+	// Setup metric provider. This comes first so the batch span processors
+	// find it when they register their own metrics.
 	meterProvider := sdkmetric.NewMeterProvider(
-		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)),
-		sdkmetric.WithResource(res),
+		append(metricOpts, sdkmetric.WithResource(res))...,
 	)
 	otel.SetMeterProvider(meterProvider)
 
-	// Return cleanup function
-	return func(ctx context.Context) {
-		tracerProvider.Shutdown(ctx)
-		meterProvider.Shutdown(ctx)
+	if len(cfg.GlobalSpanAttributes) > 0 {
+		traceOpts = append(traceOpts, sdktrace.WithSpanProcessor(newGlobalAttributes(cfg.GlobalSpanAttributes)))
+	}
+	if cfg.SpanPercentileSamples > 0 {
+		rootSpans = newRootSpanDurations(cfg.SpanPercentileSamples)
+		traceOpts = append(traceOpts, sdktrace.WithSpanProcessor(rootSpans))
+	}
+
+	// Tracking every open span costs a lock per span start and end, so only
+	// when the leak watch or KEEP_ATTRIBUTES' ancestor marking needs it
+	if cfg.MaxSpanDuration > 0 || len(cfg.KeepAttributes) > 0 {
+		traceOpts = append(traceOpts, sdktrace.WithSpanProcessor(activeSpans))
+	}
+
+	// Setup trace provider
+	tracerProvider := sdktrace.NewTracerProvider(
+		append(traceOpts,
+			sdktrace.WithResource(res),
+			sdktrace.WithSpanProcessor(consistencySpans{}),
+		)...,
+	)
+	if cfg.InstrumentationOverhead {
+		otel.SetTracerProvider(timedTracerProvider{tracerProvider})
+	} else {
+		otel.SetTracerProvider(tracerProvider)
+	}
+
+	// Setup log provider. Records still go to stdout; the OTLP copy carries
+	// severity numbers mapped from the slog levels.
+	var loggerProvider *sdklog.LoggerProvider
+	if cfg.LogsExporter == "otlp" {
+		var logOpts []sdklog.LoggerProviderOption
+		for _, endpoint := range cfg.OTLPLogEndpoints {
+			logExporter, err := otlploggrpc.New(ctx,
+				otlploggrpc.WithInsecure(),
+				otlploggrpc.WithEndpoint(endpoint),
+			)
+			if err != nil {
+				log.Fatalf("failed to create log exporter for %s: %v", endpoint, err)
+			}
+			logOpts = append(logOpts, sdklog.WithProcessor(sdklog.NewBatchProcessor(logExporter)))
+		}
+		loggerProvider = sdklog.NewLoggerProvider(append(logOpts, sdklog.WithResource(res))...)
+		logger = newLogger(teeHandler{jsonLogHandler, otlpLogHandler{logger: loggerProvider.Logger(scopeLogs)}})
+	}
+
+	// Return cleanup function. Each provider gets SHUTDOWN_FLUSH_TIMEOUT for
+	// its final export; the error joins a *flushTimeoutError or *flushError
+	// per signal that didn't make it.
+	return func() error {
+		// The signals flush side by side, so a stuck one doesn't eat into
+		// the others' timeouts
+		var traceErr, metricErr, logErr error
+		flushes := []func(){
+			func() { traceErr = flushSignal("traces", cfg.ShutdownFlushTimeout, tracerProvider.Shutdown) },
+			func() { metricErr = flushSignal("metrics", cfg.ShutdownFlushTimeout, meterProvider.Shutdown) },
+		}
+		if loggerProvider != nil {
+			flushes = append(flushes, func() {
+				logErr = flushSignal("logs", cfg.ShutdownFlushTimeout, loggerProvider.Shutdown)
+			})
+		}
+		backgroundPool.run(flushes...)
+		return errors.Join(traceErr, metricErr, logErr)
 	}
 }
 
@@ -120,31 +535,123 @@ func healthzHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("OK"))
 }
 
+func readyzHandler(w http.ResponseWriter, r *http.Request) {
+	if lameDuck.Load() {
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	if cfg.CollectorRequiredForReady && !collectorsReachable.Load() {
+		http.Error(w, "collector unreachable", http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("OK"))
+}
+
+// Methods /work answers; anything else is treated like GET
+const workAllowedMethods = "GET, POST, HEAD, OPTIONS"
+
 func workHandler(w http.ResponseWriter, r *http.Request) {
-	start := time.Now()
+	start := now()
 	status := http.StatusOK
 
+	// OPTIONS skips the simulated work but is still counted
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Allow", workAllowedMethods)
+		w.WriteHeader(http.StatusNoContent)
+		reqDuration.With(reqLabels(r.Method, http.StatusNoContent)).
+			Observe(since(start).Seconds())
+		return
+	}
+
 	ctx := r.Context()
 	span := trace.SpanFromContext(ctx)
 
 	traceID := span.SpanContext().TraceID().String()
-	log := logger.With(
-		"trace_id", traceID,
-		"span_id", span.SpanContext().SpanID().String(),
-	)
+	log := requestLogger(ctx)
 
-	// Nested span to simulate work
-	_, childSpan := otel.Tracer("app").Start(ctx, "simulate_work")
-	latency := time.Duration(rand.Intn(400)) * time.Millisecond
-	time.Sleep(latency)
+	// Nested span to simulate work. The request span also gets start/end
+	// events per phase, so the timeline survives SPAN_DETAIL=basic.
+	span.AddEvent("work_start")
+	_, childSpan := startPhase(ctx, "simulate_work")
+	workLatency := time.Duration(rand.Intn(400)) * time.Millisecond
+	if evaluateFlag(ctx, workFastPathFlag, "off") == "on" {
+		workLatency /= 4
+	}
+	latency := workLatency + incidentLatency() + anomalyLatency() + regionLatency()
+	log.DebugContext(ctx, "simulating work",
+		"latency_ms", latency.Milliseconds(),
+		"incident_latency_ms", incidentLatency().Milliseconds(),
+		"anomaly_latency_ms", anomalyLatency().Milliseconds(),
+		"region_latency_ms", regionLatency().Milliseconds(),
+		"failure_rate", effectiveFailureRate("work"),
+	)
+	workQueueDepth.Add(1)
+	abortErr := sleepCtx(ctx, latency)
+	workQueueDepth.Add(-1)
 	childSpan.End()
+	span.AddEvent("work_end")
+
+	var depErr error
+	if abortErr == nil {
+		if err := lookupCache(ctx); errors.Is(err, errDBQuery) {
+			depErr = err
+		} else {
+			abortErr = err
+		}
+	}
+	if abortErr == nil && depErr == nil && cfg.GRPCDepEnabled {
+		depErr = callGRPCDependency(ctx)
+	}
+	if abortErr == nil && depErr == nil && cfg.DownstreamURL != "" {
+		depErr = callDownstream(ctx)
+	}
+	if ctx.Err() != nil {
+		abortErr = ctx.Err()
+	}
+
+	// With EXEMPLAR_LOG_ID the outcome log line carries an ID that goes into
+	// the exemplar too, so a histogram bucket links to the exact log record
+	var logID string
+	if cfg.ExemplarLogID {
+		logID = newLogID()
+		log = log.With("log_id", logID)
+	}
+
+	switch {
+	case abortErr != nil:
+		status = abortRequest(ctx, w, "work", log)
+	case errors.As(depErr, new(*rateLimitedError)):
+		// Pass the rate limit on rather than blaming the dependency
+		status = http.StatusServiceUnavailable
+		log.Warn("dependency rate limited",
+			"latency_ms", latency.Milliseconds(),
+			"status", status,
+			"error", depErr,
+		)
+
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+	case depErr != nil:
+		status = http.StatusBadGateway
+		log.Error("dependency call failed",
+			"latency_ms", latency.Milliseconds(),
+			"status", status,
+			"error", depErr,
+		)
 
-	_, cacheSpan := otel.Tracer("app").Start(ctx, "db_cache_lookup")
-	time.Sleep(time.Duration(rand.Intn(200)) * time.Millisecond)
-	cacheSpan.End()
+		http.Error(w, "Bad Gateway", http.StatusBadGateway)
+	case rand.Float64() < degradeRatio():
+		status = http.StatusServiceUnavailable
+		log.Error("request failed",
+			"latency_ms", latency.Milliseconds(),
+			"status", status,
+			"reason", "degraded",
+		)
 
-	// the code fails 20% of the time
-	if rand.Float32() < 0.2 {
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+	case rand.Float64() < effectiveFailureRate("work"):
+		// the code fails FAILURE_RATE of the time (20% by default) unless
+		// ROUTE_FAILURE_RATES says otherwise, more during a simulated incident
 		status = http.StatusInternalServerError
 		log.Error("request failed",
 			"latency_ms", latency.Milliseconds(),
@@ -152,25 +659,59 @@ func workHandler(w http.ResponseWriter, r *http.Request) {
 		)
 
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-	} else {
+	default:
 		log.Info("request succeeded",
-		"latency_ms", latency.Milliseconds(),
-		"status", status,
+			"latency_ms", latency.Milliseconds(),
+			"status", status,
 		)
+		maybeWarn(log)
 
-		w.Write([]byte("Work completed\n"))
-	}	
+		// HEAD goes through the same work and timing, minus the body
+		body := []byte("Work completed\n")
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		if r.Method != http.MethodHead {
+			w.Write(body)
+		}
+	}
 
 	// Record request duration with exemplar
-	duration := time.Since(start).Seconds()
-    obs := reqDuration.WithLabelValues(r.Method, strconv.Itoa(status))
-    
-    // If exemplar observer is supported, attach trace ID
-    if exemplarObs, ok := obs.(prometheus.ExemplarObserver); ok && traceID != "" {
-		log.Info("Attaching exemplar", "traceID", traceID, "duration", duration) 
-        exemplarObs.ObserveWithExemplar(duration, prometheus.Labels{"traceID": traceID})
-    } else {
-		log.Warn("Exemplar not supported or traceID empty", "traceID", traceID, "ok", ok)
-        obs.Observe(duration)
-    }
+	duration := since(start).Seconds()
+	recordStart := time.Now()
+	defer func() { addOverhead(ctx, time.Since(recordStart)) }()
+	labels := reqLabels(r.Method, status)
+	obs := reqDuration.With(labels)
+	// Lets a trace be found from the histogram bucket it landed in
+	bucket := durationBucket(duration)
+	span.SetAttributes(attribute.String("http.duration_bucket", bucket))
+	if reqSummary != nil {
+		reqSummary.With(labels).Observe(duration)
+	}
+	if utf8Duration != nil {
+		observeUTF8Duration(r.Method, status, duration)
+	}
+	if cfg.ApdexTarget > 0 {
+		recordApdex(since(start), status >= http.StatusInternalServerError)
+	}
+	recordSLO(status < http.StatusInternalServerError)
+
+	// Attach the trace ID as an exemplar when the trace is actually kept;
+	// an unsampled trace ID would point at nothing. EXEMPLAR_MODE=errors
+	// saves the exemplar slots for failing requests.
+	eligible := cfg.EnableExemplars && span.SpanContext().IsSampled() &&
+		(cfg.ExemplarMode != "errors" || status >= http.StatusInternalServerError)
+	if eligible {
+		exemplarEligible.Inc()
+	}
+	observeRequest(log, obs, eligible, labels["method"]+" "+labels["status"], bucket, duration, traceID, logID)
+}
+
+// durationBucket returns the le label of the histogram bucket d falls into
+func durationBucket(d float64) string {
+	for _, upper := range reqDurationBuckets {
+		if d <= upper {
+			return strconv.FormatFloat(upper, 'f', -1, 64)
+		}
+	}
+	return "+Inf"
 }
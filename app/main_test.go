@@ -0,0 +1,188 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+func TestLameDuckFailsReadinessOnly(t *testing.T) {
+	app := startApp(t, "LAMEDUCK_DURATION=3s")
+
+	if resp, _ := app.get("/readyz"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("readyz before SIGTERM = %d, want 200", resp.StatusCode)
+	}
+	app.signal(syscall.SIGTERM)
+	app.lineContaining("Entering lame duck mode")
+
+	if resp, _ := app.get("/readyz"); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("readyz in lame duck = %d, want 503", resp.StatusCode)
+	}
+	if resp, _ := app.get("/fanout"); resp.StatusCode != http.StatusOK {
+		t.Errorf("fanout in lame duck = %d, want 200", resp.StatusCode)
+	}
+
+	start := time.Now()
+	if code := app.stop(); code != 0 {
+		t.Errorf("exit code = %d, want 0", code)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("shutdown took %s after the lame duck window", time.Since(start))
+	}
+}
+
+func TestRequestSummaryQuantiles(t *testing.T) {
+	app := startApp(t,
+		"REQUEST_SUMMARY_ENABLED=true",
+		"REQUEST_SUMMARY_OBJECTIVES=0.5:0.05,0.75:0.01",
+	)
+
+	app.getConcurrently(5, "/work")
+	mf := app.scrape()["http_request_duration_summary"]
+	if mf == nil || len(mf.GetMetric()) != 1 {
+		t.Fatalf("want one http_request_duration_summary series, got %v", mf)
+	}
+	s := mf.GetMetric()[0].GetSummary()
+	if s.GetSampleCount() != 5 {
+		t.Errorf("summary count = %d, want 5", s.GetSampleCount())
+	}
+	var quantiles []float64
+	for _, q := range s.GetQuantile() {
+		quantiles = append(quantiles, q.GetQuantile())
+		if q.GetValue() <= 0 {
+			t.Errorf("quantile %v = %v, want a positive duration", q.GetQuantile(), q.GetValue())
+		}
+	}
+	if !slices.Equal(quantiles, []float64{0.5, 0.75}) {
+		t.Errorf("quantiles = %v, want [0.5 0.75]", quantiles)
+	}
+	if _, ok := app.scrape()["http_request_duration_seconds"]; !ok {
+		t.Error("the histogram is gone with the summary enabled")
+	}
+}
+
+func TestDurationBucket(t *testing.T) {
+	for d, want := range map[float64]string{
+		0.001: "0.005",
+		0.005: "0.005",
+		0.15:  "0.25",
+		1:     "1",
+		42:    "+Inf",
+	} {
+		if got := durationBucket(d); got != want {
+			t.Errorf("durationBucket(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+// The attribute must name the bucket the same observation landed in
+func TestDurationBucketAttributeMatchesHistogram(t *testing.T) {
+	app := startApp(t, "REGION=ap-south")
+
+	app.get("/work")
+	bucket := app.spansNamed("work", 1)[0].attr("http.duration_bucket")
+	mf := app.scrape()["http_request_duration_seconds"]
+	if mf == nil || len(mf.GetMetric()) != 1 {
+		t.Fatalf("want one http_request_duration_seconds series, got %v", mf)
+	}
+	var le string
+	for _, b := range mf.GetMetric()[0].GetHistogram().GetBucket() {
+		if b.GetCumulativeCount() == 1 {
+			le = strconv.FormatFloat(b.GetUpperBound(), 'f', -1, 64)
+			break
+		}
+	}
+	if le == "" {
+		le = "+Inf"
+	}
+	if bucket != le {
+		t.Errorf("http.duration_bucket = %v, but the request landed in le=%s", bucket, le)
+	}
+	// At least the 150ms region latency
+	if le == "0.005" || le == "0.01" || le == "0.025" || le == "0.05" || le == "0.1" {
+		t.Errorf("request landed in le=%s, faster than its 150ms of added latency", le)
+	}
+}
+
+func TestWorkHeadAndOptions(t *testing.T) {
+	app := startApp(t)
+
+	resp, body := app.do(http.MethodHead, "/work", nil)
+	if resp.StatusCode != http.StatusOK || body != "" {
+		t.Errorf("HEAD: status %d with body %q, want 200 and no body", resp.StatusCode, body)
+	}
+	if cl := resp.Header.Get("Content-Length"); cl != strconv.Itoa(len("Work completed\n")) {
+		t.Errorf("HEAD: Content-Length = %q, want the GET body's length", cl)
+	}
+
+	resp, body = app.do(http.MethodOptions, "/work", nil)
+	if resp.StatusCode != http.StatusNoContent || body != "" {
+		t.Errorf("OPTIONS: status %d with body %q, want 204 and no body", resp.StatusCode, body)
+	}
+	if allow := resp.Header.Get("Allow"); allow != workAllowedMethods {
+		t.Errorf("OPTIONS: Allow = %q, want %q", allow, workAllowedMethods)
+	}
+
+	families := app.scrape()
+	for _, series := range [][]string{
+		{"method", "HEAD", "status", "200"},
+		{"method", "OPTIONS", "status", "204"},
+	} {
+		if v, _ := seriesValue(families, "http_request_duration_seconds", series...); v != 1 {
+			t.Errorf("http_request_duration_seconds%v count = %v, want 1", series, v)
+		}
+	}
+	for _, method := range []string{"HEAD", "OPTIONS"} {
+		found := false
+		for _, s := range app.spansNamed("work", 2) {
+			found = found || s.attr("http.request.method") == method
+		}
+		if !found {
+			t.Errorf("no work span with http.request.method %s", method)
+		}
+	}
+}
+
+const protobufAccept = "application/vnd.google.protobuf; proto=io.prometheus.client.MetricFamily; encoding=delimited"
+
+func TestProtobufScrapeWithNativeHistogram(t *testing.T) {
+	app := startApp(t, "NATIVE_HISTOGRAMS=true")
+
+	app.get("/work")
+	resp, body := app.get("/metrics", "Accept", protobufAccept)
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/vnd.google.protobuf") {
+		t.Fatalf("Content-Type = %q, want protobuf", ct)
+	}
+
+	dec := expfmt.NewDecoder(strings.NewReader(body), expfmt.NewFormat(expfmt.TypeProtoDelim))
+	var hist *dto.Histogram
+	for {
+		var mf dto.MetricFamily
+		if err := dec.Decode(&mf); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if mf.GetName() == "http_request_duration_seconds" {
+			hist = mf.GetMetric()[0].GetHistogram()
+		}
+	}
+	if hist == nil {
+		t.Fatal("no http_request_duration_seconds in the protobuf scrape")
+	}
+	if hist.Schema == nil || len(hist.GetPositiveSpan()) == 0 {
+		t.Errorf("histogram has no native buckets: schema %v, spans %v", hist.Schema, hist.GetPositiveSpan())
+	}
+	if len(hist.GetBucket()) == 0 {
+		t.Error("classic buckets are missing next to the native ones")
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"runtime"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Instrumentation scopes for the OTel instruments. Each becomes its own scope
+// in the OTLP output, so the backend can tell server, runtime and app
+// metrics apart.
+const (
+	scopeHTTPServer = "http.server"
+	scopeRuntime    = "runtime"
+	scopeWork       = "app.work"
+)
+
+// registerMeters creates every OTel instrument the app exports
+func registerMeters() error {
+	return errors.Join(
+		registerHTTPServerMeters(),
+		registerRuntimeMeters(),
+		registerWorkQueueGauge(),
+	)
+}
+
+func registerHTTPServerMeters() error {
+	_, err := otel.Meter(scopeHTTPServer).Int64ObservableUpDownCounter("http.server.active_requests",
+		metric.WithDescription("Requests currently being handled"),
+		metric.WithUnit("{request}"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(inFlightCount.Load())
+			return nil
+		}),
+	)
+	return err
+}
+
+func registerRuntimeMeters() error {
+	m := otel.Meter(scopeRuntime)
+	goroutines, err := m.Int64ObservableUpDownCounter("go.goroutine.count",
+		metric.WithDescription("Live goroutines"),
+		metric.WithUnit("{goroutine}"),
+	)
+	if err != nil {
+		return err
+	}
+	heap, err := m.Int64ObservableUpDownCounter("go.memory.used",
+		metric.WithDescription("Heap memory in use"),
+		metric.WithUnit("By"),
+	)
+	if err != nil {
+		return err
+	}
+	_, err = m.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		o.ObserveInt64(goroutines, int64(runtime.NumGoroutine()))
+		o.ObserveInt64(heap, int64(ms.HeapInuse))
+		return nil
+	}, goroutines, heap)
+	return err
+}
@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestMetersGroupedByScope(t *testing.T) {
+	reader := useManualReader(t)
+	if err := registerMeters(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]string{}
+	for _, sm := range collect(t, reader).ScopeMetrics {
+		for _, m := range sm.Metrics {
+			got[m.Name] = sm.Scope.Name
+		}
+	}
+	for name, scope := range map[string]string{
+		"http.server.active_requests": scopeHTTPServer,
+		"go.goroutine.count":          scopeRuntime,
+		"go.memory.used":              scopeRuntime,
+		"app.work.queue_depth":        scopeWork,
+	} {
+		if got[name] != scope {
+			t.Errorf("%s is under scope %q, want %q", name, got[name], scope)
+		}
+	}
+}
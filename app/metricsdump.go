@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+// dumpMetrics writes the final Prometheus exposition to path, so the state
+// at shutdown survives without a scraper having caught it
+func dumpMetrics(path string) error {
+	mfs, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		return fmt.Errorf("gather: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	enc := expfmt.NewEncoder(f, expfmt.NewFormat(expfmt.TypeTextPlain))
+	for _, mf := range mfs {
+		if err := enc.Encode(mf); err != nil {
+			f.Close()
+			return fmt.Errorf("encode %s: %w", mf.GetName(), err)
+		}
+	}
+	return f.Close()
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/common/expfmt"
+)
+
+func TestMetricsDumpOnShutdown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.prom")
+	app := startApp(t, "METRICS_DUMP_FILE="+path)
+
+	app.get("/work")
+	app.get("/work")
+	if code := app.stop(); code != 0 {
+		t.Fatalf("exit code = %d, want 0", code)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("no metrics dump: %v", err)
+	}
+	defer f.Close()
+	families, err := new(expfmt.TextParser).TextToMetricFamilies(f)
+	if err != nil {
+		t.Fatalf("dump is not valid exposition: %v", err)
+	}
+	if v, ok := seriesValue(families, "http_request_duration_seconds", "method", "GET", "status", "200"); v != 2 {
+		t.Errorf("dumped request histogram count = %v (present %v), want 2", v, ok)
+	}
+	app.lineContaining("Wrote final metrics to " + path)
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCustomMetricsPath(t *testing.T) {
+	app := startApp(t, "METRICS_PATH=/internal/metrics")
+	app.get("/work")
+
+	if v := app.metricValue("http_request_duration_seconds"); v != 1 {
+		t.Errorf("request histogram at /internal/metrics has %v samples, want 1", v)
+	}
+	if resp, _ := app.get("/metrics"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("/metrics status = %d, want 404 with METRICS_PATH set", resp.StatusCode)
+	}
+}
+
+func TestMetricsPathMustBeAbsolute(t *testing.T) {
+	app := launchApp(t, "METRICS_PATH=metrics")
+	if code := app.wait(); code == 0 {
+		t.Error("app started with a relative METRICS_PATH")
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// handle registers h under pattern with tracing and the middleware shared
+// by every instrumented endpoint
+func handle(pattern, name string, h http.Handler) {
+	// Innermost, so a panic still passes through the logging middleware as
+	// an ordinary 500
+	h = withRecovery(h)
+	h = withRouteFailures(h, name)
+	h = withAuth(h, name)
+	h = withColdStart(h, name)
+	h = withTimeout(h, name)
+	h = withFingerprint(h)
+	h = withConcurrencyLimit(h, name)
+	h = withAccessLog(h, name)
+	h = withSlowRequestLog(h)
+	h = withClientInfo(h)
+	h = withHeaderCapture(h)
+	h = withProtocolInfo(h)
+	h = withAudit(h, name)
+	h = withRoute(h, name)
+	http.Handle(pattern, withOverhead(withForcedTraceID(otelhttp.NewHandler(h, name,
+		otelhttp.WithPropagators(inboundPropagator),
+	), name), name))
+}
+
+type routeKey struct{}
+
+// withRoute records the route name in the request context, so logs can be
+// grouped by the same route the spans are named after
+func withRoute(next http.Handler, route string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), routeKey{}, route)))
+	})
+}
+
+// requestLogger returns the logger for a request, correlated with its span
+// and labeled with its route
+func requestLogger(ctx context.Context) *slog.Logger {
+	sc := trace.SpanContextFromContext(ctx)
+	route, _ := ctx.Value(routeKey{}).(string)
+	return logger.With(
+		"trace_id", sc.TraceID().String(),
+		"span_id", sc.SpanID().String(),
+		"route", route,
+	)
+}
+
+var inFlight = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Requests currently being served",
+	},
+)
+
+var concurrencyRejections = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "http_concurrency_rejections_total",
+		Help: "Requests rejected with 503 because MAX_CONCURRENT_REQUESTS were already in flight",
+	},
+)
+
+var inFlightCount atomic.Int64
+
+// withConcurrencyLimit tracks in-flight requests and sheds load with a 503
+// once more than cfg.MaxConcurrentRequests are being served. Probes are
+// counted but never shed, so a busy instance isn't also restarted; neither
+// are the /admin/consistency self-test's requests.
+func withConcurrencyLimit(next http.Handler, route string) http.Handler {
+	sheddable := route != "healthz" && route != "readyz"
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlightCount.Add(1)
+		inFlight.Inc()
+		recordPeak(r.Context(), n)
+		defer func() {
+			inFlightCount.Add(-1)
+			inFlight.Dec()
+		}()
+
+		if sheddable && !isConsistencyProbe(r) && cfg.MaxConcurrentRequests > 0 && n > int64(cfg.MaxConcurrentRequests) {
+			concurrencyRejections.Inc()
+			http.Error(w, "server busy", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// statusRecorder remembers the status code written by the wrapped handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// withFingerprint stamps the server span with trace.group, a hash of the
+// route and status, so the backend can group traces of the same kind
+func withFingerprint(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !cfg.TraceFingerprint {
+			next.ServeHTTP(w, r)
+			return
+		}
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		trace.SpanFromContext(r.Context()).SetAttributes(
+			attribute.String("trace.group", fingerprint(r.Pattern, rec.status)),
+		)
+	})
+}
+
+func fingerprint(route string, status int) string {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s %d", route, status)
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// withAccessLog writes one record per request. Errors (4xx/5xx) are always
+// logged; everything else is sampled at ACCESS_LOG_SAMPLE_RATIO to keep the
+// volume down without losing error visibility.
+func withAccessLog(next http.Handler, route string) http.Handler {
+	if !cfg.AccessLog {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		if rec.status < 400 && rand.Float64() >= cfg.AccessLogSampleRatio {
+			return
+		}
+
+		sc := trace.SpanContextFromContext(r.Context())
+		logger.Info("access",
+			"route", route,
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"trace_id", sc.TraceID().String(),
+			"span_id", sc.SpanID().String(),
+		)
+	})
+}
+
+// withSlowRequestLog warns about every request slower than SLOW_REQUEST_MS,
+// whatever its status, so slow successes don't hide among the info logs
+func withSlowRequestLog(next http.Handler) http.Handler {
+	if cfg.SlowRequestThreshold <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if d := time.Since(start); d > cfg.SlowRequestThreshold {
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+			requestLogger(r.Context()).Warn("slow request",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", rec.status,
+				"latency_ms", d.Milliseconds(),
+				"threshold_ms", cfg.SlowRequestThreshold.Milliseconds(),
+			)
+		}
+	})
+}
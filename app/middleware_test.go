@@ -0,0 +1,56 @@
+package main
+
+import "testing"
+
+func TestTraceFingerprint(t *testing.T) {
+	app := startApp(t, "TRACE_FINGERPRINT=true")
+
+	app.get("/fanout?n=1")
+	app.get("/fanout?n=2")
+	app.get("/fanout?n=0")
+	app.get("/healthz")
+
+	fanout := app.spansNamed("fanout", 3)
+	healthz := app.spansNamed("healthz", 1)[0]
+	group := func(s exportedSpan) any { return s.attr("trace.group") }
+	if group(fanout[0]) == nil || group(fanout[0]) != group(fanout[1]) {
+		t.Errorf("same route and status: trace.group %v vs %v, want equal", group(fanout[0]), group(fanout[1]))
+	}
+	if group(fanout[1]) == group(fanout[2]) {
+		t.Errorf("same route, 200 vs 400: trace.group both %v", group(fanout[1]))
+	}
+	if group(fanout[0]) == group(healthz) {
+		t.Errorf("different routes, both 200: trace.group both %v", group(fanout[0]))
+	}
+}
+
+func TestAccessLogSamplesOnlySuccesses(t *testing.T) {
+	app := startApp(t, "ACCESS_LOG=true", "ACCESS_LOG_SAMPLE_RATIO=0.1")
+
+	for range 40 {
+		app.get("/healthz")
+	}
+	for range 20 {
+		app.get("/fanout?n=0")
+	}
+
+	app.waitFor("20 error access records", func() bool {
+		n := 0
+		for _, rec := range app.logs() {
+			if rec["msg"] == "access" && rec["status"] == float64(400) {
+				n++
+			}
+		}
+		return n == 20
+	})
+	var ok int
+	for _, rec := range app.logs() {
+		if rec["msg"] == "access" && rec["status"] == float64(200) {
+			ok++
+		}
+	}
+	// 4 expected; even 20 of 40 at 10% is vanishingly unlikely
+	if ok >= 20 {
+		t.Errorf("%d of 40 successful requests logged at a 0.1 sample ratio", ok)
+	}
+}
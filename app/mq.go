@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const mqTopic = "orders"
+
+var messagesProcessed = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "messages_processed_total",
+		Help: "Synthetic queue messages the background consumer has processed",
+	},
+)
+
+// Offsets of the last message produced and the last one consumed; the
+// difference is the consumer's lag, as Kafka reports it
+var mqProduced, mqConsumed atomic.Int64
+
+var consumerLag = prometheus.NewGaugeFunc(
+	prometheus.GaugeOpts{
+		Name: "consumer_lag",
+		Help: "Messages produced to the synthetic queue but not yet processed",
+	},
+	func() float64 { return float64(mqProduced.Load() - mqConsumed.Load()) },
+)
+
+type mqMessage struct {
+	offset int64
+	// The publish span, which the process span links back to
+	producer trace.SpanContext
+}
+
+// runMessageQueue starts a synthetic producer publishing MQ_PRODUCE_RATE
+// messages per second and a consumer taking MQ_PROCESS_TIME for each. Every
+// message crosses from one trace into another, so the consumer's span is a
+// root linked to the producer's rather than its child, the way messaging
+// instrumentation models batch and async delivery.
+func runMessageQueue() {
+	queue := make(chan mqMessage, cfg.MQQueueSize)
+	go produceMessages(queue)
+	consumeMessages(queue)
+}
+
+func produceMessages(queue chan<- mqMessage) {
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / cfg.MQProduceRate))
+	defer ticker.Stop()
+	for range ticker.C {
+		offset := mqProduced.Load() + 1
+		_, span := otel.Tracer("app").Start(context.Background(), mqTopic+" publish",
+			trace.WithSpanKind(trace.SpanKindProducer),
+			trace.WithAttributes(mqAttributes(offset)...),
+			trace.WithAttributes(semconv.MessagingOperationPublish),
+		)
+		span.End()
+		// Blocks once the queue is full, like a producer waiting on a broker
+		queue <- mqMessage{offset: offset, producer: span.SpanContext()}
+		mqProduced.Store(offset)
+	}
+}
+
+func consumeMessages(queue <-chan mqMessage) {
+	for msg := range queue {
+		_, span := otel.Tracer("app").Start(context.Background(), mqTopic+" process",
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithNewRoot(),
+			trace.WithLinks(trace.Link{SpanContext: msg.producer}),
+			trace.WithAttributes(mqAttributes(msg.offset)...),
+			trace.WithAttributes(semconv.MessagingOperationProcess),
+		)
+		time.Sleep(cfg.MQProcessTime)
+		span.End()
+		mqConsumed.Store(msg.offset)
+		messagesProcessed.Inc()
+	}
+}
+
+func mqAttributes(offset int64) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		semconv.MessagingSystem("synthetic"),
+		semconv.MessagingDestinationName(mqTopic),
+		semconv.MessagingMessageID(strconv.FormatInt(offset, 10)),
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConsumerLagGrowsWithSlowConsumer(t *testing.T) {
+	// 50 messages a second in, 20 out
+	app := startApp(t, "MQ_ENABLED=true", "MQ_PRODUCE_RATE=50", "MQ_PROCESS_TIME=50ms")
+
+	var lag1, processed1 float64
+	app.waitFor("the consumer to fall behind", func() bool {
+		f := app.scrape()
+		lag1, _ = seriesValue(f, "consumer_lag")
+		processed1, _ = seriesValue(f, "messages_processed_total")
+		return lag1 >= 5 && processed1 >= 1
+	})
+	time.Sleep(time.Second)
+	f := app.scrape()
+	lag2, _ := seriesValue(f, "consumer_lag")
+	processed2, _ := seriesValue(f, "messages_processed_total")
+	if lag2 <= lag1 {
+		t.Errorf("consumer_lag went from %v to %v, want it to keep growing", lag1, lag2)
+	}
+	// Roughly 20 a second, whatever the backlog
+	if d := processed2 - processed1; d < 10 || d > 30 {
+		t.Errorf("processed %v messages in a second, want about 20", d)
+	}
+}
+
+func TestConsumerKeepsUp(t *testing.T) {
+	app := startApp(t, "MQ_ENABLED=true", "MQ_PRODUCE_RATE=20", "MQ_PROCESS_TIME=1ms")
+	app.waitFor("20 messages processed", func() bool {
+		v, _ := seriesValue(app.scrape(), "messages_processed_total")
+		return v >= 20
+	})
+	if v := app.metricValue("consumer_lag"); v > 2 {
+		t.Errorf("consumer_lag = %v with a fast consumer, want at most 2", v)
+	}
+
+	// Each process span is its own trace, linked to its publish span
+	publish := map[string]string{}
+	for _, s := range app.spansNamed("orders publish", 5) {
+		publish[s.SpanContext.SpanID] = s.attr("messaging.message.id").(string)
+	}
+	matched := 0
+	for _, s := range app.spansNamed("orders process", 5) {
+		if len(s.Links) != 1 {
+			t.Fatalf("process span has %d links, want 1", len(s.Links))
+		}
+		link := s.Links[0].SpanContext
+		if link.TraceID == s.SpanContext.TraceID {
+			t.Error("process span is in its producer's trace, want a new one")
+		}
+		if id, ok := publish[link.SpanID]; ok {
+			matched++
+			if id != s.attr("messaging.message.id") {
+				t.Errorf("message %v links to the publish span of message %s", s.attr("messaging.message.id"), id)
+			}
+		}
+	}
+	if matched == 0 {
+		t.Error("no process span links to an exported publish span")
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/json"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type orphanResult struct {
+	TraceID         string `json:"trace_id"`
+	SpanID          string `json:"span_id"`
+	MissingParentID string `json:"missing_parent_span_id"`
+}
+
+// orphanSpanHandler emits a span in the request's trace whose parent is a
+// made-up span ID that is never exported, so the backend has to show a span
+// with a missing parent
+func orphanSpanHandler(w http.ResponseWriter, r *http.Request) {
+	var parentID trace.SpanID
+	crand.Read(parentID[:])
+	parent := trace.SpanContextFromContext(r.Context()).WithSpanID(parentID).WithRemote(true)
+
+	ctx := trace.ContextWithRemoteSpanContext(context.Background(), parent)
+	_, span := otel.Tracer("app").Start(ctx, "orphan",
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(attribute.String("orphan.missing_parent_span_id", parentID.String())),
+	)
+	span.End()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(orphanResult{
+		TraceID:         span.SpanContext().TraceID().String(),
+		SpanID:          span.SpanContext().SpanID().String(),
+		MissingParentID: parentID.String(),
+	})
+}
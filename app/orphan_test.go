@@ -0,0 +1,35 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestOrphanSpanParent(t *testing.T) {
+	app := startApp(t)
+	_, body := app.get("/admin/orphan-span")
+	var res orphanResult
+	if err := json.Unmarshal([]byte(body), &res); err != nil {
+		t.Fatalf("decoding %q: %v", body, err)
+	}
+
+	orphan := app.spansNamed("orphan", 1)[0]
+	if orphan.Parent.SpanID != res.MissingParentID {
+		t.Errorf("orphan parent = %s, want the injected %s", orphan.Parent.SpanID, res.MissingParentID)
+	}
+	if orphan.SpanContext.TraceID != res.TraceID || orphan.SpanContext.SpanID != res.SpanID {
+		t.Errorf("orphan span %s/%s, response says %s/%s",
+			orphan.SpanContext.TraceID, orphan.SpanContext.SpanID, res.TraceID, res.SpanID)
+	}
+
+	// The parent is never exported, though the trace's server span is
+	server := app.spansNamed("admin_orphan_span", 1)[0]
+	if server.SpanContext.TraceID != res.TraceID {
+		t.Errorf("orphan is in trace %s, request in %s", res.TraceID, server.SpanContext.TraceID)
+	}
+	for _, s := range app.spans() {
+		if s.SpanContext.SpanID == res.MissingParentID {
+			t.Errorf("the missing parent %s was exported as %q", res.MissingParentID, s.Name)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	otellog "go.opentelemetry.io/otel/log"
+)
+
+// Scope OTLP log records are emitted under
+const scopeLogs = "app.logs"
+
+// otelSeverity maps a slog level onto the OTLP severity number range. Both
+// put their named levels 4 apart with INFO at the same place (slog 0, OTLP
+// 9), so DEBUG, INFO, WARN and ERROR land on the OTLP values of the same
+// name and levels in between keep their order instead of collapsing into
+// one severity.
+func otelSeverity(level slog.Level) otellog.Severity {
+	n := int(level) + int(otellog.SeverityInfo)
+	switch {
+	case n < int(otellog.SeverityTrace1):
+		return otellog.SeverityTrace1
+	case n > int(otellog.SeverityFatal4):
+		return otellog.SeverityFatal4
+	}
+	return otellog.Severity(n)
+}
+
+// otlpLogHandler is a slog.Handler emitting each record through the OTel
+// logs API, for OTEL_LOGS_EXPORTER=otlp. Groups become dotted key prefixes.
+type otlpLogHandler struct {
+	logger otellog.Logger
+	attrs  []otellog.KeyValue
+	prefix string
+}
+
+func (h otlpLogHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h otlpLogHandler) Handle(ctx context.Context, r slog.Record) error {
+	var rec otellog.Record
+	rec.SetTimestamp(r.Time)
+	rec.SetObservedTimestamp(time.Now())
+	rec.SetSeverity(otelSeverity(r.Level))
+	rec.SetSeverityText(r.Level.String())
+	rec.SetBody(otellog.StringValue(r.Message))
+	rec.AddAttributes(h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		rec.AddAttributes(otelKeyValue(h.prefix, a))
+		return true
+	})
+	h.logger.Emit(ctx, rec)
+	return nil
+}
+
+func (h otlpLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	kvs := append([]otellog.KeyValue{}, h.attrs...)
+	for _, a := range attrs {
+		kvs = append(kvs, otelKeyValue(h.prefix, a))
+	}
+	return otlpLogHandler{logger: h.logger, attrs: kvs, prefix: h.prefix}
+}
+
+func (h otlpLogHandler) WithGroup(name string) slog.Handler {
+	return otlpLogHandler{logger: h.logger, attrs: h.attrs, prefix: h.prefix + name + "."}
+}
+
+func otelKeyValue(prefix string, a slog.Attr) otellog.KeyValue {
+	return otellog.KeyValue{Key: prefix + a.Key, Value: otelValue(a.Value)}
+}
+
+func otelValue(v slog.Value) otellog.Value {
+	v = v.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return otellog.StringValue(v.String())
+	case slog.KindInt64:
+		return otellog.Int64Value(v.Int64())
+	case slog.KindUint64:
+		return otellog.Int64Value(int64(v.Uint64()))
+	case slog.KindFloat64:
+		return otellog.Float64Value(v.Float64())
+	case slog.KindBool:
+		return otellog.BoolValue(v.Bool())
+	case slog.KindDuration:
+		return otellog.StringValue(v.Duration().String())
+	case slog.KindTime:
+		return otellog.StringValue(v.Time().Format(time.RFC3339Nano))
+	case slog.KindGroup:
+		var kvs []otellog.KeyValue
+		for _, a := range v.Group() {
+			kvs = append(kvs, otelKeyValue("", a))
+		}
+		return otellog.MapValue(kvs...)
+	}
+	if err, ok := v.Any().(error); ok {
+		return otellog.StringValue(err.Error())
+	}
+	return otellog.StringValue(fmt.Sprint(v.Any()))
+}
+
+// teeHandler hands every record to all of its handlers, so logs keep going
+// to stdout while also being exported
+type teeHandler []slog.Handler
+
+func (t teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range t {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range t {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(teeHandler, len(t))
+	for i, h := range t {
+		out[i] = h.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (t teeHandler) WithGroup(name string) slog.Handler {
+	out := make(teeHandler, len(t))
+	for i, h := range t {
+		out[i] = h.WithGroup(name)
+	}
+	return out
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"testing"
+
+	otellog "go.opentelemetry.io/otel/log"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+func TestOTelSeverity(t *testing.T) {
+	for _, tc := range []struct {
+		level slog.Level
+		want  otellog.Severity
+	}{
+		{slog.LevelDebug, otellog.SeverityDebug},
+		{slog.LevelInfo, otellog.SeverityInfo},
+		{slog.LevelWarn, otellog.SeverityWarn},
+		{slog.LevelError, otellog.SeverityError},
+		// In-between levels keep their order
+		{slog.LevelInfo + 1, otellog.SeverityInfo2},
+		{slog.LevelError + 2, otellog.SeverityError3},
+		// Beyond OTLP's range, clamped
+		{slog.LevelDebug - 10, otellog.SeverityTrace1},
+		{slog.LevelError + 20, otellog.SeverityFatal4},
+	} {
+		if got := otelSeverity(tc.level); got != tc.want {
+			t.Errorf("otelSeverity(%v) = %v, want %v", tc.level, got, tc.want)
+		}
+	}
+}
+
+func TestOTLPLogSeverities(t *testing.T) {
+	// The dependency answers the second request with a 404
+	stub := startStub(t, http.StatusOK, http.StatusNotFound)
+	app := startApp(t,
+		"DOWNSTREAM_URL="+stub.URL,
+		"OTEL_LOGS_EXPORTER=otlp",
+		"OTEL_BLRP_SCHEDULE_DELAY=50",
+		"DEBUG_HEADER_ENABLED=true",
+		"WARN_RATE=1",
+	)
+	app.get("/work", "X-Debug", "1") // debug, info and a simulated warning
+	app.get("/work")                 // error
+
+	want := map[string]logspb.SeverityNumber{
+		"simulating work":        logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG,
+		"request succeeded":      logspb.SeverityNumber_SEVERITY_NUMBER_INFO,
+		"dependency call failed": logspb.SeverityNumber_SEVERITY_NUMBER_ERROR,
+	}
+	got := map[string]*logspb.LogRecord{}
+	var warning *logspb.LogRecord
+	app.waitFor("a record of every level", func() bool {
+		for _, rl := range app.collector.resourceLogs() {
+			for _, sl := range rl.GetScopeLogs() {
+				for _, r := range sl.GetLogRecords() {
+					got[r.GetBody().GetStringValue()] = r
+					for _, kv := range r.GetAttributes() {
+						if kv.GetKey() == "simulated" {
+							warning = r
+						}
+					}
+				}
+			}
+		}
+		for msg := range want {
+			if got[msg] == nil {
+				return false
+			}
+		}
+		return warning != nil
+	})
+	for msg, sev := range want {
+		if n := got[msg].GetSeverityNumber(); n != sev {
+			t.Errorf("%q exported with severity %v, want %v", msg, n, sev)
+		}
+	}
+	if n := warning.GetSeverityNumber(); n != logspb.SeverityNumber_SEVERITY_NUMBER_WARN {
+		t.Errorf("simulated warning exported with severity %v, want WARN", n)
+	}
+	if s := warning.GetSeverityText(); s != "WARN" {
+		t.Errorf("simulated warning severity text = %q, want WARN", s)
+	}
+}
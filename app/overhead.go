@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var instrumentationOverhead = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name: "instrumentation_overhead_seconds",
+		Help: "Time each request spent starting and ending spans and recording metrics",
+		Buckets: []float64{
+			.000001, .0000025, .000005, .00001, .000025, .00005,
+			.0001, .00025, .0005, .001, .0025, .005, .01,
+		},
+	},
+	[]string{"route"},
+)
+
+type overheadKey struct{}
+
+// addOverhead charges d of telemetry work to the request behind ctx, if
+// INSTRUMENTATION_OVERHEAD is measuring it
+func addOverhead(ctx context.Context, d time.Duration) {
+	if total, ok := ctx.Value(overheadKey{}).(*atomic.Int64); ok {
+		total.Add(int64(d))
+	}
+}
+
+// withOverhead sits outside otelhttp, so the server span's own start and
+// end are charged to the request too
+func withOverhead(next http.Handler, route string) http.Handler {
+	if !cfg.InstrumentationOverhead {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		total := new(atomic.Int64)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), overheadKey{}, total)))
+		instrumentationOverhead.WithLabelValues(route).Observe(time.Duration(total.Load()).Seconds())
+	})
+}
+
+// timedTracerProvider times every span start and end made through it
+type timedTracerProvider struct {
+	trace.TracerProvider
+}
+
+func (p timedTracerProvider) Tracer(name string, opts ...trace.TracerOption) trace.Tracer {
+	return timedTracer{p.TracerProvider.Tracer(name, opts...)}
+}
+
+type timedTracer struct {
+	trace.Tracer
+}
+
+func (t timedTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	start := time.Now()
+	ctx, span := t.Tracer.Start(ctx, name, opts...)
+	timed := timedSpan{Span: span, ctx: ctx}
+	ctx = trace.ContextWithSpan(ctx, timed)
+	addOverhead(ctx, time.Since(start))
+	return ctx, timed
+}
+
+type timedSpan struct {
+	trace.Span
+	ctx context.Context
+}
+
+func (s timedSpan) End(opts ...trace.SpanEndOption) {
+	start := time.Now()
+	s.Span.End(opts...)
+	addOverhead(s.ctx, time.Since(start))
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// histogramSum adds up the sample sums of every series of a histogram family
+func histogramSum(f *dto.MetricFamily) float64 {
+	var sum float64
+	for _, m := range f.GetMetric() {
+		sum += m.GetHistogram().GetSampleSum()
+	}
+	return sum
+}
+
+func TestInstrumentationOverhead(t *testing.T) {
+	app := startApp(t, "INSTRUMENTATION_OVERHEAD=true", "REGION=ap-south")
+	app.getConcurrently(10, "/work")
+
+	f := app.scrape()
+	if n, _ := seriesValue(f, "instrumentation_overhead_seconds", "route", "work"); n != 10 {
+		t.Fatalf("instrumentation_overhead_seconds{route=work} has %v samples, want 10", n)
+	}
+	overhead := histogramSum(f["instrumentation_overhead_seconds"])
+	total := histogramSum(f["http_request_duration_seconds"])
+	if overhead <= 0 {
+		t.Errorf("overhead sum = %v, want some measured time", overhead)
+	}
+	// ap-south keeps every /work above 150ms, nearly all of it sleeping;
+	// telemetry should be a sliver of that
+	if overhead > total/100 {
+		t.Errorf("overhead %vs is more than 1%% of the %vs spent serving", overhead, total)
+	}
+}
+
+func TestInstrumentationOverheadOff(t *testing.T) {
+	app := startApp(t)
+	app.get("/work")
+	if _, ok := seriesValue(app.scrape(), "instrumentation_overhead_seconds", "route", "work"); ok {
+		t.Error("overhead measured without INSTRUMENTATION_OVERHEAD")
+	}
+}
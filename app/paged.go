@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var pagesProcessed = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "pages_processed_total",
+		Help: "Pages fetched by /paged",
+	},
+)
+
+// Upper bound on the pages a single /paged request may walk through
+const maxPages = 50
+
+// pagedHandler walks through N pages one after the other, each in its own
+// child span, the way a client paging through an API or a stream consumer
+// shows up in a trace: a staircase of short sequential spans
+func pagedHandler(w http.ResponseWriter, r *http.Request) {
+	pages := 5
+	if raw := r.URL.Query().Get("pages"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 1 || v > maxPages {
+			http.Error(w, fmt.Sprintf("pages must be between 1 and %d", maxPages), http.StatusBadRequest)
+			return
+		}
+		pages = v
+	}
+
+	ctx := r.Context()
+	log := requestLogger(ctx)
+
+	items := 0
+	for page := 1; page <= pages; page++ {
+		_, pageSpan := otel.Tracer("app").Start(ctx, "page",
+			trace.WithSpanKind(trace.SpanKindInternal),
+			trace.WithAttributes(attribute.Int("page.number", page)),
+		)
+		err := sleepCtx(ctx, time.Duration(10+rand.Intn(40))*time.Millisecond)
+		pageItems := 10 + rand.Intn(90)
+		pageSpan.SetAttributes(attribute.Int("page.items", pageItems))
+		pageSpan.End()
+		if err != nil {
+			abortRequest(ctx, w, "paged", log)
+			return
+		}
+		pagesProcessed.Inc()
+		items += pageItems
+	}
+
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.Int("paged.pages", pages),
+		attribute.Int("paged.items", items),
+	)
+	log.Info("pages processed", "pages", pages, "items", items)
+	fmt.Fprintf(w, "Processed %d pages, %d items\n", pages, items)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPagedSpans(t *testing.T) {
+	app := startApp(t)
+	if resp, body := app.get("/paged?pages=3"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+
+	server := app.spansNamed("paged", 1)[0]
+	pages := app.spansNamed("page", 3)
+	if len(pages) != 3 {
+		t.Fatalf("got %d page spans, want 3", len(pages))
+	}
+	for i, p := range pages {
+		if v := p.attr("page.number"); v != float64(i+1) {
+			t.Errorf("page span %d has page.number %v, want %d", i, v, i+1)
+		}
+		if p.Parent.SpanID != server.SpanContext.SpanID {
+			t.Errorf("page %d isn't a child of the request span", i+1)
+		}
+		// One after the other, not overlapping
+		if i > 0 && p.StartTime.Before(pages[i-1].EndTime) {
+			t.Errorf("page %d started before page %d ended", i+1, i)
+		}
+	}
+	if v := server.attr("paged.pages"); v != float64(3) {
+		t.Errorf("paged.pages = %v, want 3", v)
+	}
+	if v := app.metricValue("pages_processed_total"); v != 3 {
+		t.Errorf("pages_processed_total = %v, want 3", v)
+	}
+}
+
+func TestPagedBounds(t *testing.T) {
+	app := startApp(t)
+	for _, q := range []string{"0", "51", "x"} {
+		if resp, _ := app.get("/paged?pages=" + q); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("pages=%s gave %d, want 400", q, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// canonicalPath lowercases p and drops any trailing slash, so /WORK and
+// /work/ both become /work
+func canonicalPath(p string) string {
+	p = strings.ToLower(p)
+	if len(p) > 1 {
+		p = strings.TrimRight(p, "/")
+	}
+	if p == "" {
+		return "/"
+	}
+	return p
+}
+
+// normalizePaths sits in front of the mux so near-miss paths reach their
+// handler instead of an untraced 404. "rewrite" serves the canonical route
+// directly; "redirect" answers 308 so clients learn the canonical path.
+func normalizePaths(next http.Handler, mode string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		canonical := canonicalPath(r.URL.Path)
+		if canonical == r.URL.Path {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if mode == "redirect" {
+			u := *r.URL
+			u.Path, u.RawPath = canonical, ""
+			http.Redirect(w, r, u.String(), http.StatusPermanentRedirect)
+			return
+		}
+		r2 := r.Clone(r.Context())
+		r2.URL.Path, r2.URL.RawPath = canonical, ""
+		next.ServeHTTP(w, r2)
+	})
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCanonicalPath(t *testing.T) {
+	for in, want := range map[string]string{
+		"/work":   "/work",
+		"/work/":  "/work",
+		"/WORK":   "/work",
+		"/Work//": "/work",
+		"/":       "/",
+		"//":      "/",
+	} {
+		if got := canonicalPath(in); got != want {
+			t.Errorf("canonicalPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPathNormalizationRewrite(t *testing.T) {
+	app := startApp(t, "PATH_NORMALIZATION=rewrite")
+	for _, p := range []string{"/work/", "/WORK"} {
+		if resp, _ := app.get(p); resp.StatusCode != http.StatusOK {
+			t.Errorf("%s gave %d, want 200", p, resp.StatusCode)
+		}
+	}
+	for _, s := range app.spansNamed("work", 2) {
+		if v := s.attr("http.route"); v != "/work" {
+			t.Errorf("http.route = %v, want /work", v)
+		}
+	}
+	if v := app.metricValue("http_request_duration_seconds", "method", "GET", "status", "200"); v != 2 {
+		t.Errorf("canonical series has %v samples, want 2", v)
+	}
+}
+
+func TestPathNormalizationRedirect(t *testing.T) {
+	app := startApp(t, "PATH_NORMALIZATION=redirect")
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(app.url + "/WORK/?x=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusPermanentRedirect || resp.Header.Get("Location") != "/work?x=1" {
+		t.Errorf("got %d to %q, want 308 to /work?x=1", resp.StatusCode, resp.Header.Get("Location"))
+	}
+}
+
+func TestPathNormalizationOff(t *testing.T) {
+	app := startApp(t)
+	if resp, _ := app.get("/WORK"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("/WORK gave %d without normalization, want 404", resp.StatusCode)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// High-water mark of in-flight requests and the request that set it, so a
+// concurrency spike can be traced back to its trace
+var peak struct {
+	sync.Mutex
+	InFlight int64     `json:"in_flight"`
+	TraceID  string    `json:"trace_id"`
+	At       time.Time `json:"at"`
+}
+
+func recordPeak(ctx context.Context, n int64) {
+	peak.Lock()
+	defer peak.Unlock()
+	if n <= peak.InFlight {
+		return
+	}
+	peak.InFlight = n
+	peak.TraceID = trace.SpanContextFromContext(ctx).TraceID().String()
+	peak.At = time.Now()
+}
+
+func peakTraceHandler(w http.ResponseWriter, r *http.Request) {
+	peak.Lock()
+	defer peak.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&peak)
+}
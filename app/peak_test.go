@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestPeakTraceFromConcurrentRequests(t *testing.T) {
+	app := startApp(t)
+
+	// Each /work request takes a few hundred milliseconds, so these overlap
+	app.getConcurrently(10, "/work")
+	_, body := app.get("/admin/peak-trace")
+	var got struct {
+		InFlight int64  `json:"in_flight"`
+		TraceID  string `json:"trace_id"`
+	}
+	if err := json.Unmarshal([]byte(body), &got); err != nil {
+		t.Fatalf("bad JSON %q: %v", body, err)
+	}
+	if got.InFlight < 2 {
+		t.Errorf("in_flight = %d, want the concurrent requests to have overlapped", got.InFlight)
+	}
+	found := false
+	for _, s := range app.spansNamed("work", 10) {
+		found = found || s.SpanContext.TraceID == got.TraceID
+	}
+	if !found {
+		t.Errorf("trace_id %s is not one of the /work requests", got.TraceID)
+	}
+}
+
+func TestRecordPeakKeepsHighWaterMark(t *testing.T) {
+	withTrace := func(b byte) context.Context {
+		sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{b}, SpanID: trace.SpanID{1}})
+		return trace.ContextWithSpanContext(context.Background(), sc)
+	}
+	peak.InFlight, peak.TraceID = 0, ""
+
+	recordPeak(withTrace(1), 1)
+	recordPeak(withTrace(2), 3)
+	recordPeak(withTrace(3), 2)
+	recordPeak(withTrace(4), 3)
+	if peak.InFlight != 3 || peak.TraceID != (trace.TraceID{2}).String() {
+		t.Errorf("peak = %d from %s, want 3 from the request that first reached it", peak.InFlight, peak.TraceID)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+func TestDownstreamPeerService(t *testing.T) {
+	stub := startStub(t, http.StatusOK)
+	u, _ := url.Parse(stub.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	app := startApp(t, "DOWNSTREAM_URL="+stub.URL, "DOWNSTREAM_PEER_SERVICE=inventory")
+	app.get("/work")
+
+	client := app.spansNamed("GET", 1)[0]
+	if v := client.attr("peer.service"); v != "inventory" {
+		t.Errorf("peer.service = %v, want inventory", v)
+	}
+	if v := client.attr("server.address"); v != "127.0.0.1" {
+		t.Errorf("server.address = %v, want 127.0.0.1", v)
+	}
+	if v := client.attr("server.port"); v != float64(port) {
+		t.Errorf("server.port = %v, want %d", v, port)
+	}
+}
+
+func TestDownstreamPeerServiceDefaultsToHost(t *testing.T) {
+	stub := startStub(t, http.StatusOK)
+	app := startApp(t, "DOWNSTREAM_URL="+stub.URL)
+	app.get("/work")
+
+	if v := app.spansNamed("GET", 1)[0].attr("peer.service"); v != "127.0.0.1" {
+		t.Errorf("peer.service = %v, want the URL's host", v)
+	}
+}
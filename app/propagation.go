@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Propagators used to extract context from incoming requests and to inject
+// it into outgoing ones. Keeping them separate lets the app bridge formats,
+// e.g. accept B3 from a legacy upstream and speak W3C downstream.
+var (
+	inboundPropagator  propagation.TextMapPropagator = propagation.TraceContext{}
+	outboundPropagator propagation.TextMapPropagator = propagation.TraceContext{}
+)
+
+// buildPropagator combines propagators by their OTEL_PROPAGATORS names
+func buildPropagator(names []string) (propagation.TextMapPropagator, error) {
+	var props []propagation.TextMapPropagator
+	for _, name := range names {
+		switch name {
+		case "tracecontext":
+			props = append(props, propagation.TraceContext{})
+		case "baggage":
+			props = append(props, propagation.Baggage{})
+		case "b3":
+			props = append(props, b3.New())
+		case "b3multi":
+			props = append(props, b3.New(b3.WithInjectEncoding(b3.B3MultipleHeader)))
+		case "custom":
+			props = append(props, customPropagator{header: cfg.CustomTraceHeader, format: cfg.CustomTraceFormat})
+		case "none":
+		default:
+			return nil, fmt.Errorf("unsupported propagator %q", name)
+		}
+	}
+	return propagation.NewCompositeTextMapPropagator(props...), nil
+}
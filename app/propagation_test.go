@@ -0,0 +1,32 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestB3InW3COut(t *testing.T) {
+	stub := startStub(t, http.StatusOK)
+	app := startApp(t,
+		"OTEL_PROPAGATORS=b3",
+		"OUTBOUND_PROPAGATORS=tracecontext",
+		"DOWNSTREAM_URL="+stub.URL,
+	)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	// The downstream call comes first, so a failed /work still made it
+	app.get("/work", "b3", traceID+"-00f067aa0ba902b7-1")
+
+	reqs := stub.requests()
+	if len(reqs) != 1 {
+		t.Fatalf("downstream saw %d requests, want 1", len(reqs))
+	}
+	tp := reqs[0].Get("traceparent")
+	if parts := strings.Split(tp, "-"); len(parts) != 4 || parts[1] != traceID {
+		t.Errorf("downstream traceparent = %q, want it to continue trace %s", tp, traceID)
+	}
+	if b3 := reqs[0].Get("b3"); b3 != "" {
+		t.Errorf("downstream also got b3 %q, want W3C only", b3)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func propagationTest(t *testing.T, app *testApp) propagationResult {
+	t.Helper()
+	_, body := app.get("/admin/propagation-test")
+	var res propagationResult
+	if err := json.Unmarshal([]byte(body), &res); err != nil {
+		t.Fatalf("bad JSON %q: %v", body, err)
+	}
+	return res
+}
+
+func TestPropagationTestPasses(t *testing.T) {
+	app := startApp(t)
+
+	res := propagationTest(t, app)
+	if !res.Pass {
+		t.Fatalf("propagation test failed with matching propagators: %+v", res)
+	}
+	if res.ReceivedTraceID != res.SentTraceID || res.ReceivedParentID != res.SentSpanID {
+		t.Errorf("result = %+v, want the probe under the sent span", res)
+	}
+	// The exported spans agree with the report
+	client := app.spansNamed("propagation-test", 1)[0]
+	probe := app.spansNamed("propagation_probe", 1)[0]
+	if probe.Parent.SpanID != client.SpanContext.SpanID || probe.SpanContext.TraceID != client.SpanContext.TraceID {
+		t.Errorf("probe span parent %s/%s, want the client span %s/%s",
+			probe.SpanContext.TraceID, probe.Parent.SpanID, client.SpanContext.TraceID, client.SpanContext.SpanID)
+	}
+}
+
+func TestPropagationTestFailsOnMismatch(t *testing.T) {
+	app := startApp(t, "OTEL_PROPAGATORS=b3", "OUTBOUND_PROPAGATORS=tracecontext")
+
+	if res := propagationTest(t, app); res.Pass || res.Reason == "" {
+		t.Errorf("result = %+v, want a failure with a reason", res)
+	}
+}
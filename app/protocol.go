@@ -0,0 +1,39 @@
+package main
+
+import (
+	"crypto/tls"
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var requestsByProtocol = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "http_requests_by_protocol_total",
+		Help: "Requests by HTTP protocol version and TLS version (none for cleartext)",
+	},
+	[]string{"protocol", "tls_version"},
+)
+
+// withProtocolInfo counts requests by protocol and records the negotiated TLS
+// parameters on the server span. otelhttp already sets
+// network.protocol.version; the cipher suite stays off the metric, where it
+// would multiply the series for little benefit.
+func withProtocolInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		protocol := strconv.Itoa(r.ProtoMajor) + "." + strconv.Itoa(r.ProtoMinor)
+		tlsVersion := "none"
+		if r.TLS != nil {
+			tlsVersion = tls.VersionName(r.TLS.Version)
+			trace.SpanFromContext(r.Context()).SetAttributes(
+				attribute.String("tls.protocol.version", tlsVersion),
+				attribute.String("tls.cipher", tls.CipherSuiteName(r.TLS.CipherSuite)),
+			)
+		}
+		requestsByProtocol.WithLabelValues(protocol, tlsVersion).Inc()
+		next.ServeHTTP(w, r)
+	})
+}
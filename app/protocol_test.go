@@ -0,0 +1,32 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestHTTP2ProtocolAttribute(t *testing.T) {
+	app := startApp(t, "H2C=true")
+
+	// Prior-knowledge HTTP/2 over cleartext
+	tr := &http.Transport{Protocols: new(http.Protocols)}
+	tr.Protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: tr}
+	defer tr.CloseIdleConnections()
+	resp, err := client.Get(app.url + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.ProtoMajor != 2 {
+		t.Fatalf("response came over %s, want HTTP/2", resp.Proto)
+	}
+
+	span := app.spansNamed("healthz", 1)[0]
+	if v := span.attr("network.protocol.version"); v != "2.0" {
+		t.Errorf("network.protocol.version = %v, want 2.0", v)
+	}
+	if v := app.metricValue("http_requests_by_protocol_total", "protocol", "2.0", "tls_version", "none"); v != 1 {
+		t.Errorf("HTTP/2 requests counted = %v, want 1", v)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Requests currently inside /work's simulate_work phase, standing in for the
+// depth of a work queue
+var workQueueDepth atomic.Int64
+
+// registerWorkQueueGauge exposes workQueueDepth as an asynchronous OTel
+// gauge. Unlike the synchronous instruments, nothing is recorded on the
+// request path: the SDK invokes the callback whenever it collects.
+func registerWorkQueueGauge() error {
+	_, err := otel.Meter(scopeWork).Int64ObservableGauge("app.work.queue_depth",
+		metric.WithDescription("Requests currently in the simulated work phase"),
+		metric.WithUnit("{request}"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(workQueueDepth.Load())
+			return nil
+		}),
+	)
+	return err
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// useManualReader installs a global MeterProvider read by the returned
+// reader, so instruments created afterwards can be collected on demand
+func useManualReader(t *testing.T) *sdkmetric.ManualReader {
+	t.Helper()
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	otel.SetMeterProvider(mp)
+	t.Cleanup(func() { mp.Shutdown(context.Background()) })
+	return reader
+}
+
+func collect(t *testing.T, reader *sdkmetric.ManualReader) metricdata.ResourceMetrics {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	return rm
+}
+
+func TestWorkQueueGaugeCallback(t *testing.T) {
+	reader := useManualReader(t)
+	if err := registerWorkQueueGauge(); err != nil {
+		t.Fatal(err)
+	}
+	defer workQueueDepth.Store(0)
+
+	for _, depth := range []int64{7, 2} {
+		workQueueDepth.Store(depth)
+		var got []int64
+		for _, sm := range collect(t, reader).ScopeMetrics {
+			for _, m := range sm.Metrics {
+				if m.Name != "app.work.queue_depth" {
+					continue
+				}
+				g, ok := m.Data.(metricdata.Gauge[int64])
+				if !ok {
+					t.Fatalf("app.work.queue_depth is a %T, want a gauge", m.Data)
+				}
+				for _, dp := range g.DataPoints {
+					got = append(got, dp.Value)
+				}
+			}
+		}
+		if len(got) != 1 || got[0] != depth {
+			t.Errorf("app.work.queue_depth = %v, want [%d]", got, depth)
+		}
+	}
+}
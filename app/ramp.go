@@ -0,0 +1,19 @@
+package main
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// rampGauge moves at exactly RAMP_RATE per second from RAMP_START, so
+// deriv() and delta() based alerts can be tested against a known slope.
+// The value is computed at scrape time, so it is the same whatever the
+// scrape interval.
+var rampGauge = prometheus.NewGaugeFunc(
+	prometheus.GaugeOpts{
+		Name: "synthetic_ramp_value",
+		Help: "Synthetic gauge changing by RAMP_RATE per second since start-up",
+	},
+	func() float64 { return cfg.RampStart + cfg.RampRate*time.Since(startTime).Seconds() },
+)
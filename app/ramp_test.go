@@ -0,0 +1,43 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRampGaugeSlope(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		rate string
+		want float64
+	}{
+		{"up", "10", 10},
+		{"down", "-4", -4},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			app := startApp(t, "RAMP_RATE="+tc.rate, "RAMP_START=100")
+			first := app.metricValue("synthetic_ramp_value")
+			start := time.Now()
+			time.Sleep(time.Second)
+			second := app.metricValue("synthetic_ramp_value")
+			elapsed := time.Since(start).Seconds()
+
+			if math.Abs(first-100) > math.Abs(tc.want) {
+				t.Errorf("first value = %v, want about RAMP_START=100", first)
+			}
+			// The two scrapes' own latency is the only slack
+			slope := (second - first) / elapsed
+			if math.Abs(slope-tc.want) > math.Abs(tc.want)*0.1 {
+				t.Errorf("slope = %.2f/s over %.2fs, want %v/s", slope, elapsed, tc.want)
+			}
+		})
+	}
+}
+
+func TestRampGaugeOffByDefault(t *testing.T) {
+	app := startApp(t)
+	if _, ok := seriesValue(app.scrape(), "synthetic_ramp_value"); ok {
+		t.Error("synthetic_ramp_value exported without RAMP_RATE")
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var rangeResponses = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "http_range_responses_total",
+		Help: "Responses from /range by status: 200 for whole payloads, 206 for ranges, 416 for unsatisfiable ones",
+	},
+	[]string{"status"},
+)
+
+// Fixed modification time, so If-Range with a date works across requests
+var rangeModTime = time.Now()
+
+// rangePayload builds RANGE_PAYLOAD_BYTES of repeating 0-9a-z, so any slice
+// of it can be checked by eye against its offset
+func rangePayload(size int) []byte {
+	const alphabet = "0123456789abcdefghijklmnopqrstuvwxyz"
+	return bytes.Repeat([]byte(alphabet), size/len(alphabet)+1)[:size]
+}
+
+// rangeHandler serves the payload with Range support left to
+// http.ServeContent: one range is a 206 with Content-Range, several a
+// multipart/byteranges 206, and out-of-bounds ones a 416
+func rangeHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	span := trace.SpanFromContext(ctx)
+	log := requestLogger(ctx)
+
+	rec := &statusRecorder{ResponseWriter: w}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(rec, r, "", rangeModTime, bytes.NewReader(rangePayload(cfg.RangePayloadBytes)))
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rangeResponses.WithLabelValues(strconv.Itoa(rec.status)).Inc()
+
+	// Same keys as CAPTURE_*_HEADERS would give them, recorded whether or
+	// not those list the headers
+	span.SetAttributes(headerAttributes("http.request.header.", r.Header, []string{"Range"})...)
+	span.SetAttributes(headerAttributes("http.response.header.", w.Header(), []string{"Content-Range"})...)
+	span.SetAttributes(
+		attribute.Int("range.payload_bytes", cfg.RangePayloadBytes),
+		attribute.Bool("range.partial", rec.status == http.StatusPartialContent),
+	)
+	requested := r.Header.Get("Range")
+	contentRange := w.Header().Get("Content-Range")
+	log.Info("range served",
+		"range", requested,
+		"content_range", contentRange,
+		"status", rec.status,
+	)
+}
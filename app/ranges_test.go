@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRangeRequestPartialContent(t *testing.T) {
+	app := startApp(t, "RANGE_PAYLOAD_BYTES=100")
+	resp, body := app.get("/range", "Range", "bytes=10-19")
+	if resp.StatusCode != http.StatusPartialContent {
+		t.Fatalf("status = %d, want 206", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Content-Range"); got != "bytes 10-19/100" {
+		t.Errorf("Content-Range = %q, want bytes 10-19/100", got)
+	}
+	if body != "abcdefghij" {
+		t.Errorf("body = %q, want bytes 10-19 of the payload", body)
+	}
+	if resp, _ := app.get("/range", "Range", "bytes=200-"); resp.StatusCode != http.StatusRequestedRangeNotSatisfiable {
+		t.Errorf("out-of-bounds range: status = %d, want 416", resp.StatusCode)
+	}
+	if resp, body := app.get("/range"); resp.StatusCode != http.StatusOK || len(body) != 100 {
+		t.Errorf("whole payload: status %d with %d bytes, want 200 with 100", resp.StatusCode, len(body))
+	}
+
+	var partial *exportedSpan
+	for _, s := range app.spansNamed("range", 3) {
+		if s.attr("http.response.status_code") == float64(206) {
+			partial = &s
+		}
+	}
+	if partial == nil {
+		t.Fatal("no range span with status 206")
+	}
+	if got := toStrings(partial.attr("http.request.header.range")); len(got) != 1 || got[0] != "bytes=10-19" {
+		t.Errorf("http.request.header.range = %v, want [bytes=10-19]", got)
+	}
+	if got := toStrings(partial.attr("http.response.header.content-range")); len(got) != 1 || got[0] != "bytes 10-19/100" {
+		t.Errorf("http.response.header.content-range = %v, want [bytes 10-19/100]", got)
+	}
+	if partial.attr("range.partial") != true || partial.attr("range.payload_bytes") != float64(100) {
+		t.Errorf("range.partial = %v, range.payload_bytes = %v, want true and 100", partial.attr("range.partial"), partial.attr("range.payload_bytes"))
+	}
+
+	families := app.scrape()
+	for _, status := range []string{"206", "416", "200"} {
+		if v, _ := seriesValue(families, "http_range_responses_total", "status", status); v != 1 {
+			t.Errorf("http_range_responses_total{status=%q} = %v, want 1", status, v)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// startRateLimitedStub answers the first limited requests with 429 and
+// Retry-After, then 200s, and records when each request arrived
+func startRateLimitedStub(t *testing.T, limited int, retryAfter string) (*httptest.Server, func() []time.Time) {
+	t.Helper()
+	var mu sync.Mutex
+	var arrivals []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		arrivals = append(arrivals, time.Now())
+		n := len(arrivals)
+		mu.Unlock()
+		if n <= limited {
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]time.Time(nil), arrivals...)
+	}
+}
+
+func TestDownstreamRateLimitBackoffAndRetry(t *testing.T) {
+	stub, arrivals := startRateLimitedStub(t, 1, "1")
+	app := startApp(t, "DOWNSTREAM_URL="+stub.URL, "DOWNSTREAM_RETRY_BACKOFF=1ms")
+
+	if resp, _ := app.get("/work"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200 after the retry", resp.StatusCode)
+	}
+	at := arrivals()
+	if len(at) != 2 {
+		t.Fatalf("downstream saw %d requests, want 2", len(at))
+	}
+	// Retry-After replaces the 1ms backoff
+	if gap := at[1].Sub(at[0]); gap < 900*time.Millisecond {
+		t.Errorf("retried after %v, want Retry-After's 1s", gap)
+	}
+	if v := app.metricValue("downstream_rate_limited_total"); v != 1 {
+		t.Errorf("downstream_rate_limited_total = %v, want 1", v)
+	}
+
+	var retryAfter any
+	app.waitFor("the rate_limited span event", func() bool {
+		for _, s := range app.spansNamed("GET", 1) {
+			for _, e := range s.Events {
+				if e.Name == "rate_limited" {
+					retryAfter = attrValue(e.Attributes, "http.retry_after_ms")
+					return true
+				}
+			}
+		}
+		return false
+	})
+	if retryAfter != float64(1000) {
+		t.Errorf("http.retry_after_ms = %v, want 1000", retryAfter)
+	}
+}
+
+func TestDownstreamRateLimitTooLongSurfaces503(t *testing.T) {
+	stub, arrivals := startRateLimitedStub(t, 1, "60")
+	app := startApp(t, "DOWNSTREAM_URL="+stub.URL, "DOWNSTREAM_MAX_RETRY_AFTER=2s")
+
+	if resp, _ := app.get("/work"); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", resp.StatusCode)
+	}
+	if n := len(arrivals()); n != 1 {
+		t.Errorf("downstream saw %d requests, want no retry past the Retry-After cap", n)
+	}
+	app.logsWithMsg("dependency rate limited")
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var panicsRecovered = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "http_panics_recovered_total",
+		Help: "Handler panics turned into 500 responses",
+	},
+)
+
+// withRecovery turns a handler panic into a 500 instead of a dropped
+// connection, records it on the server span and logs it with the stack.
+// The response carries the trace ID in X-Trace-Id, and in the body as well
+// with PANIC_TRACE_IN_BODY, so whoever hit the panic can find its trace.
+func withRecovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w}
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+			ctx := r.Context()
+			span := trace.SpanFromContext(ctx)
+			err := fmt.Errorf("panic: %v", p)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			panicsRecovered.Inc()
+			requestLogger(ctx).Error("panic recovered", "error", err, "stack", string(debug.Stack()))
+
+			// Too late for a 500 if the handler already started its response
+			if rec.status != 0 {
+				return
+			}
+			traceID := span.SpanContext().TraceID().String()
+			w.Header().Set("X-Trace-Id", traceID)
+			if cfg.PanicTraceInBody {
+				http.Error(w, "Internal Server Error (trace_id "+traceID+")", http.StatusInternalServerError)
+				return
+			}
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// panicHandler panics, to exercise withRecovery
+func panicHandler(w http.ResponseWriter, r *http.Request) {
+	panic("simulated panic")
+}
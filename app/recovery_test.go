@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPanicResponseCarriesTraceID(t *testing.T) {
+	app := startApp(t, "PANIC_TRACE_IN_BODY=true")
+	resp, body := app.get("/admin/panic")
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", resp.StatusCode)
+	}
+
+	span := app.spansNamed("admin_panic", 1)[0]
+	traceID := span.SpanContext.TraceID
+	if !strings.Contains(body, "trace_id "+traceID) {
+		t.Errorf("body %q doesn't carry trace_id %s", body, traceID)
+	}
+	if got := resp.Header.Get("X-Trace-Id"); got != traceID {
+		t.Errorf("X-Trace-Id = %q, want %s", got, traceID)
+	}
+	if span.Status.Code != "Error" {
+		t.Errorf("span status = %+v, want Error", span.Status)
+	}
+	if len(span.Events) != 1 || attrValue(span.Events[0].Attributes, "exception.message") != "panic: simulated panic" {
+		t.Errorf("span events = %+v, want the panic recorded", span.Events)
+	}
+	if v := app.metricValue("http_panics_recovered_total"); v != 1 {
+		t.Errorf("http_panics_recovered_total = %v, want 1", v)
+	}
+}
+
+func TestPanicResponseBodyWithoutTraceID(t *testing.T) {
+	app := startApp(t)
+	resp, body := app.get("/admin/panic")
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", resp.StatusCode)
+	}
+	if strings.Contains(body, "trace_id") {
+		t.Errorf("body %q carries the trace ID without PANIC_TRACE_IN_BODY", body)
+	}
+	if resp.Header.Get("X-Trace-Id") == "" {
+		t.Error("no X-Trace-Id header")
+	}
+}
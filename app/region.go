@@ -0,0 +1,9 @@
+package main
+
+import "time"
+
+// regionLatency is the base latency REGION adds to every /work request,
+// standing in for the distance to the region's users and data
+func regionLatency() time.Duration {
+	return cfg.RegionLatencies[cfg.Region]
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegionLabelAndLatency(t *testing.T) {
+	app := startApp(t, "REGION=eu-west", "REGION_LATENCIES=eu-west=300ms,us-east=0s")
+
+	for i := 0; i < 3; i++ {
+		if resp, _ := app.get("/work"); resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d", resp.StatusCode)
+		}
+	}
+	for _, s := range app.spansNamed("work", 3) {
+		if d := s.EndTime.Sub(s.StartTime); d < 300*time.Millisecond {
+			t.Errorf("request took %v, want at least the region's 300ms", d)
+		}
+		if v := attrValue(s.Resource, "cloud.region"); v != "eu-west" {
+			t.Errorf("resource cloud.region = %v, want eu-west", v)
+		}
+	}
+	if v := app.metricValue("http_request_duration_seconds", "region", "eu-west"); v != 3 {
+		t.Errorf("histogram with region=eu-west has %v samples, want 3", v)
+	}
+}
+
+func TestRegionWithoutLatencyEntryRejected(t *testing.T) {
+	app := launchApp(t, "REGION=mars")
+	if code := app.wait(); code == 0 {
+		t.Error("app started with a REGION missing from REGION_LATENCIES")
+	}
+	if !strings.Contains(strings.Join(app.output(), "\n"), `REGION "mars" has no entry in REGION_LATENCIES`) {
+		t.Errorf("no validation error in output: %v", app.output())
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// registerCollectors registers each collector like reg.MustRegister, except
+// that a metric registered a second time (by a test, or a program embedding
+// the app and starting it again) is left as it is instead of panicking. Any
+// other registration error, such as a clashing name with different labels,
+// still panics.
+func registerCollectors(reg prometheus.Registerer, cs ...prometheus.Collector) {
+	for _, c := range cs {
+		registerOrReuse(reg, c)
+	}
+}
+
+// registerOrReuse registers c with reg and returns it, or returns the
+// equivalent collector that is already registered, so the caller records
+// into the series the registry actually exposes
+func registerOrReuse[T prometheus.Collector](reg prometheus.Registerer, c T) T {
+	err := reg.Register(c)
+	var already prometheus.AlreadyRegisteredError
+	if errors.As(err, &already) {
+		if existing, ok := already.ExistingCollector.(T); ok {
+			return existing
+		}
+	}
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func newTestCounter() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_requests_total", Help: "Test"}, []string{"route"})
+}
+
+func TestDuplicateRegistrationReusesCollector(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	first := newTestCounter()
+	registerCollectors(reg, first)
+	// A second start of the app builds and registers the metric again
+	second := registerOrReuse(reg, newTestCounter())
+	if second != first {
+		t.Error("re-registration returned a new collector instead of the registered one")
+	}
+	registerCollectors(reg, newTestCounter())
+
+	second.WithLabelValues("work").Inc()
+	first.WithLabelValues("work").Inc()
+	if n := testutil.CollectAndCount(reg, "test_requests_total"); n != 1 {
+		t.Errorf("registry exposes %d series, want 1", n)
+	}
+	if v := testutil.ToFloat64(first.WithLabelValues("work")); v != 2 {
+		t.Errorf("counter = %v, want both increments in the one series", v)
+	}
+}
+
+func TestConflictingRegistrationPanics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	registerCollectors(reg, newTestCounter())
+	defer func() {
+		if recover() == nil {
+			t.Error("registering the name with other labels didn't panic")
+		}
+	}()
+	registerCollectors(reg, prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_requests_total", Help: "Test"}, []string{"method"}))
+}
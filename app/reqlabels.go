@@ -0,0 +1,54 @@
+package main
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// Labels of the request metrics, before DROP_REQUEST_LABELS
+var reqLabelNamesAll = []string{"method", "status"}
+
+// OTel attributes matching each droppable label on otelhttp's own metrics
+var reqLabelAttributes = map[string]attribute.Key{
+	"method": "http.request.method",
+	"status": "http.response.status_code",
+}
+
+// reqLabelNames is the request metrics' label set with the dropped labels
+// taken out; their series collapse into one
+func reqLabelNames() []string {
+	var names []string
+	for _, name := range reqLabelNamesAll {
+		if !slices.Contains(cfg.DropRequestLabels, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// reqLabels returns the label values of one request for reqLabelNames
+func reqLabels(method string, status int) prometheus.Labels {
+	labels := prometheus.Labels{"method": method, "status": strconv.Itoa(status)}
+	for _, name := range cfg.DropRequestLabels {
+		delete(labels, name)
+	}
+	return labels
+}
+
+// dropRequestLabelsView strips the same labels from otelhttp's http.server.*
+// instruments, so OTLP and Prometheus dashboards see the same series
+func dropRequestLabelsView() sdkmetric.View {
+	var keys []attribute.Key
+	for _, name := range cfg.DropRequestLabels {
+		keys = append(keys, reqLabelAttributes[name])
+	}
+	filter := attribute.NewDenyKeysFilter(keys...)
+	return sdkmetric.NewView(
+		sdkmetric.Instrument{Name: "http.server.*"},
+		sdkmetric.Stream{AttributeFilter: filter},
+	)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestDropMethodLabel(t *testing.T) {
+	app := startApp(t, "DROP_REQUEST_LABELS=method", "OTEL_METRIC_EXPORT_INTERVAL=200")
+	app.get("/work")
+	app.do(http.MethodPost, "/work", nil)
+
+	fam := app.scrape()["http_request_duration_seconds"]
+	if fam == nil {
+		t.Fatal("no http_request_duration_seconds in the scrape")
+	}
+	var samples uint64
+	for _, m := range fam.GetMetric() {
+		for _, l := range m.GetLabel() {
+			if l.GetName() == "method" {
+				t.Errorf("series still has method=%q", l.GetValue())
+			}
+		}
+		samples += m.GetHistogram().GetSampleCount()
+	}
+	if samples != 2 {
+		t.Errorf("histogram holds %d samples, want both requests", samples)
+	}
+
+	// otelhttp's own instruments drop the matching attribute
+	app.waitFor("http.server.request.duration over OTLP", func() bool {
+		for _, rm := range app.collector.resourceMetrics() {
+			for _, sm := range rm.GetScopeMetrics() {
+				for _, m := range sm.GetMetrics() {
+					if m.GetName() == "http.server.request.duration" {
+						return true
+					}
+				}
+			}
+		}
+		return false
+	})
+	for _, rm := range app.collector.resourceMetrics() {
+		for _, sm := range rm.GetScopeMetrics() {
+			for _, m := range sm.GetMetrics() {
+				if m.GetName() != "http.server.request.duration" {
+					continue
+				}
+				for _, dp := range m.GetHistogram().GetDataPoints() {
+					for _, kv := range dp.GetAttributes() {
+						if kv.GetKey() == "http.request.method" {
+							t.Errorf("OTLP data point has http.request.method=%v", kv.GetValue())
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestRequestLabelsKeptByDefault(t *testing.T) {
+	app := startApp(t)
+	app.get("/work")
+	if v := app.metricValue("http_request_duration_seconds", "method", "GET", "status", "200"); v != 1 {
+		t.Errorf("GET 200 series has %v samples, want 1", v)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var retryBudgetExhausted = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "retry_budget_exhausted_total",
+		Help: "Downstream retries suppressed because the retry budget was used up",
+	},
+)
+
+// retryBudget is a token bucket shared by every downstream call: each retry
+// takes a token, and tokens come back at RETRY_BUDGET_RATE per second up to
+// RETRY_BUDGET_BURST. When a dependency is down, retries stop once the
+// bucket is empty instead of multiplying the load on it.
+var retryBudget struct {
+	sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// takeRetryToken reports whether a retry may go ahead, spending a token if so
+func takeRetryToken() bool {
+	if cfg.RetryBudgetRate <= 0 {
+		return true
+	}
+	retryBudget.Lock()
+	defer retryBudget.Unlock()
+	now := time.Now()
+	if retryBudget.last.IsZero() {
+		retryBudget.tokens = float64(cfg.RetryBudgetBurst)
+	} else {
+		refill := now.Sub(retryBudget.last).Seconds() * cfg.RetryBudgetRate
+		retryBudget.tokens = math.Min(float64(cfg.RetryBudgetBurst), retryBudget.tokens+refill)
+	}
+	retryBudget.last = now
+	if retryBudget.tokens < 1 {
+		return false
+	}
+	retryBudget.tokens--
+	return true
+}
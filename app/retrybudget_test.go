@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRetryBudgetExhaustion(t *testing.T) {
+	stub := startStub(t, http.StatusInternalServerError)
+	app := startApp(t,
+		"DOWNSTREAM_URL="+stub.URL,
+		"DOWNSTREAM_RETRY_BACKOFF=1ms",
+		"DOWNSTREAM_MAX_RETRIES=2",
+		// Three tokens, and practically no refill during the test
+		"RETRY_BUDGET_RATE=0.001",
+		"RETRY_BUDGET_BURST=3",
+	)
+
+	// The first call spends two tokens on retries, the second the last one
+	// before running dry; after that nothing is retried
+	for i := 0; i < 5; i++ {
+		app.get("/work")
+	}
+	if n := len(stub.requests()); n != 3+2+1+1+1 {
+		t.Errorf("downstream saw %d requests, want 8", n)
+	}
+	if v := app.metricValue("retry_budget_exhausted_total"); v != 4 {
+		t.Errorf("retry_budget_exhausted_total = %v, want 4", v)
+	}
+
+	exhausted := 0
+	for _, s := range app.spansNamed("GET", 8) {
+		for _, e := range s.Events {
+			if e.Name == "retry_budget_exhausted" {
+				exhausted++
+			}
+		}
+	}
+	if exhausted != 4 {
+		t.Errorf("%d retry_budget_exhausted span events, want 4", exhausted)
+	}
+}
+
+func TestRetryBudgetUnlimitedByDefault(t *testing.T) {
+	stub := startStub(t, http.StatusInternalServerError)
+	app := startApp(t, "DOWNSTREAM_URL="+stub.URL, "DOWNSTREAM_RETRY_BACKOFF=1ms", "DOWNSTREAM_MAX_RETRIES=2")
+
+	for i := 0; i < 5; i++ {
+		app.get("/work")
+	}
+	if n := len(stub.requests()); n != 15 {
+		t.Errorf("downstream saw %d requests, want every call retried twice", n)
+	}
+	if v, _ := seriesValue(app.scrape(), "retry_budget_exhausted_total"); v != 0 {
+		t.Errorf("retry_budget_exhausted_total = %v without a budget, want 0", v)
+	}
+}
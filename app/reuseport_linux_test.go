@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestReusePortDoubleBind(t *testing.T) {
+	lc := net.ListenConfig{Control: reusePortControl}
+	first, err := lc.Listen(context.Background(), "tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	addr := first.Addr().String()
+
+	second, err := lc.Listen(context.Background(), "tcp", addr)
+	if err != nil {
+		t.Fatalf("second SO_REUSEPORT bind of %s failed: %v", addr, err)
+	}
+	second.Close()
+
+	// Without the option the port is taken
+	if ln, err := net.Listen("tcp", addr); err == nil {
+		ln.Close()
+		t.Errorf("plain bind of %s succeeded while a listener holds it", addr)
+	}
+}
+
+func TestReusePortTwoInstances(t *testing.T) {
+	old := startApp(t, "REUSE_PORT=true")
+	// Same port, so the replacement can come up before the old one drains
+	next := startApp(t, "REUSE_PORT=true", "LISTEN_ADDR="+old.addr)
+	if next.addr != old.addr {
+		t.Fatalf("second instance listens on %s, want %s", next.addr, old.addr)
+	}
+	if code := old.stop(); code != 0 {
+		t.Errorf("old instance exited %d", code)
+	}
+	if resp, _ := next.get("/healthz"); resp.StatusCode != http.StatusOK {
+		t.Errorf("after the old instance stopped /healthz gave %d", resp.StatusCode)
+	}
+}
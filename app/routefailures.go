@@ -0,0 +1,35 @@
+package main
+
+import (
+	"math/rand"
+	"net/http"
+)
+
+// baseFailureRate is the failure rate a route runs at outside of incidents:
+// its ROUTE_FAILURE_RATES entry, FAILURE_RATE for /work, and 0 otherwise
+func baseFailureRate(route string) float64 {
+	if rate, ok := cfg.RouteFailureRates[route]; ok {
+		return rate
+	}
+	if route == "work" {
+		return cfg.FailureRate
+	}
+	return 0
+}
+
+// withRouteFailures fails the given share of a listed route's requests with
+// a 500 before its handler runs. /work decides its own failures, since they
+// come after the simulated work.
+func withRouteFailures(next http.Handler, route string) http.Handler {
+	if _, ok := cfg.RouteFailureRates[route]; !ok || route == "work" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rand.Float64() < effectiveFailureRate(route) {
+			requestLogger(r.Context()).Error("request failed", "status", http.StatusInternalServerError, "reason", "injected")
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
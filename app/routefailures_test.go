@@ -0,0 +1,40 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPerRouteFailureRates(t *testing.T) {
+	app := startApp(t,
+		"ROUTE_FAILURE_RATES=work=0.2,deep=0.6",
+		"FAILURE_RATE=0.9",
+		"CACHE_HIT_RATIO=1",
+	)
+	const n = 400
+	for _, tc := range []struct {
+		path string
+		want float64
+	}{
+		{"/work", 0.2},
+		{"/deep?depth=1", 0.6},
+		// Unlisted, and FAILURE_RATE only covers /work
+		{"/paged?pages=1", 0},
+	} {
+		failed := 0
+		for range n / 100 {
+			for _, status := range app.getConcurrently(100, tc.path) {
+				switch status {
+				case 500:
+					failed++
+				case 200:
+				default:
+					t.Fatalf("%s: status %d, want 200 or 500", tc.path, status)
+				}
+			}
+		}
+		if got := float64(failed) / n; math.Abs(got-tc.want) > 0.08 {
+			t.Errorf("%s: error ratio = %.3f, want about %.1f", tc.path, got, tc.want)
+		}
+	}
+}
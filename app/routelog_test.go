@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+func TestRequestLogsCarryRoute(t *testing.T) {
+	app := startApp(t, "FAILURE_RATE=0.5", "ACCESS_LOG=true")
+	app.getConcurrently(6, "/work")
+
+	spans := app.spansNamed("work", 6)
+	traces := map[string]bool{}
+	for _, s := range spans {
+		traces[s.SpanContext.TraceID] = true
+	}
+	app.waitFor("access logs for every request", func() bool {
+		n := 0
+		for _, l := range app.logs() {
+			if l["msg"] == "access" {
+				n++
+			}
+		}
+		return n == len(spans)
+	})
+
+	seen := 0
+	for _, l := range app.logs() {
+		tid, _ := l["trace_id"].(string)
+		if !traces[tid] {
+			continue
+		}
+		seen++
+		if l["route"] != "work" {
+			t.Errorf("%q record has route %v, want work", l["msg"], l["route"])
+		}
+	}
+	// At least the outcome line and the access line of each request
+	if seen < 2*len(spans) {
+		t.Errorf("found %d records for %d requests", seen, len(spans))
+	}
+}
+
+func TestAccessLogRoutePerEndpoint(t *testing.T) {
+	app := startApp(t, "ACCESS_LOG=true")
+	app.get("/healthz")
+	app.get("/work")
+
+	routes := map[string]any{}
+	app.waitFor("two access records", func() bool {
+		for _, l := range app.logs() {
+			if l["msg"] == "access" {
+				routes[l["path"].(string)] = l["route"]
+			}
+		}
+		return len(routes) == 2
+	})
+	if routes["/healthz"] != "healthz" || routes["/work"] != "work" {
+		t.Errorf("routes by path = %v, want /healthz=healthz and /work=work", routes)
+	}
+}
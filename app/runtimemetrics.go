@@ -0,0 +1,31 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// Classes of runtime/metrics that GO_RUNTIME_METRICS can switch on
+var goRuntimeMetricClasses = map[string]collectors.GoRuntimeMetricsRule{
+	"gc":     collectors.MetricsGC,
+	"memory": collectors.MetricsMemory,
+	"sched":  collectors.MetricsScheduler,
+	"all":    collectors.MetricsAll,
+}
+
+// registerGoRuntimeMetrics swaps the default Go collector, which only
+// exposes the classic MemStats-based series, for one that also reads the
+// runtime/metrics package: scheduler latency histograms, heap allocation
+// size histograms and so on, named e.g. go_sched_latencies_seconds.
+//
+// It goes straight into the default registry, next to the process collector:
+// the registry remembers the label names of unregistered series, so the
+// replacement can't gain a DEPLOYMENT_ID label the original didn't have.
+func registerGoRuntimeMetrics(classes []string) {
+	rules := make([]collectors.GoRuntimeMetricsRule, 0, len(classes))
+	for _, c := range classes {
+		rules = append(rules, goRuntimeMetricClasses[c])
+	}
+	prometheus.Unregister(collectors.NewGoCollector())
+	registerCollectors(prometheus.DefaultRegisterer, collectors.NewGoCollector(collectors.WithGoCollectorRuntimeMetrics(rules...)))
+}
@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestGoRuntimeMetricsSeries(t *testing.T) {
+	off := startApp(t).scrape()
+	if _, ok := off["go_sched_latencies_seconds"]; ok {
+		t.Error("go_sched_latencies_seconds exported without GO_RUNTIME_METRICS")
+	}
+
+	on := startApp(t, "GO_RUNTIME_METRICS=sched,gc").scrape()
+	for _, name := range []string{"go_sched_latencies_seconds", "go_gc_heap_allocs_bytes_total"} {
+		if _, ok := on[name]; !ok {
+			t.Errorf("%s missing with GO_RUNTIME_METRICS=sched,gc", name)
+		}
+	}
+	// The classic MemStats series are still there
+	if _, ok := on["go_goroutines"]; !ok {
+		t.Error("go_goroutines missing after swapping the Go collector")
+	}
+}
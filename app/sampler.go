@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// pathHashSampler samples by a hash of the request path instead of the trace
+// ID, so a given path is either always or never sampled. Handy for
+// reproducible demos; spans without a path fall back to their name.
+type pathHashSampler struct {
+	threshold uint32
+	desc      string
+}
+
+func newPathHashSampler(ratio float64) pathHashSampler {
+	return pathHashSampler{
+		threshold: uint32(ratio * float64(^uint32(0))),
+		desc:      fmt.Sprintf("PathHashSampler{%g}", ratio),
+	}
+}
+
+func (s pathHashSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	key := p.Name
+	for _, a := range p.Attributes {
+		// otelhttp sets url.path at span start, so it's here at sampling time
+		if a.Key == "url.path" {
+			key = a.Value.AsString()
+			break
+		}
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+
+	decision := sdktrace.Drop
+	if h.Sum32() < s.threshold {
+		decision = sdktrace.RecordAndSample
+	}
+	return sdktrace.SamplingResult{
+		Decision:   decision,
+		Attributes: []attribute.KeyValue{attribute.String("sampling.key", key)},
+		Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+	}
+}
+
+func (s pathHashSampler) Description() string { return s.desc }
+
+// pathHashSamplerFromConfig returns the sampler for OTEL_TRACES_SAMPLER's
+// path_hash modes, which the SDK doesn't know about. Every other value is
+// left to the SDK to read from the environment itself.
+func pathHashSamplerFromConfig() (sdktrace.Sampler, bool) {
+	if cfg.Sampler != "path_hash" && cfg.Sampler != "parentbased_path_hash" {
+		return nil, false
+	}
+	var s sdktrace.Sampler = newPathHashSampler(samplerRatio())
+	if cfg.Sampler == "parentbased_path_hash" {
+		s = sdktrace.ParentBased(s)
+	}
+	return s, true
+}
+
+// builtinSamplerFromConfig builds the SDK's own OTEL_TRACES_SAMPLER samplers,
+// for when another sampler has to wrap them and the SDK can't be left to
+// read the environment itself
+func builtinSamplerFromConfig() sdktrace.Sampler {
+	ratio := samplerRatio()
+	switch cfg.Sampler {
+	case "always_on":
+		return sdktrace.AlwaysSample()
+	case "always_off":
+		return sdktrace.NeverSample()
+	case "traceidratio":
+		return sdktrace.TraceIDRatioBased(ratio)
+	case "parentbased_always_off":
+		return sdktrace.ParentBased(sdktrace.NeverSample())
+	case "parentbased_traceidratio":
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
+	case "parentbased_always_on":
+		return sdktrace.ParentBased(sdktrace.AlwaysSample())
+	}
+	log.Fatalf("unsupported OTEL_TRACES_SAMPLER %q", cfg.Sampler)
+	return nil
+}
+
+// samplerRatio is the fraction of root spans OTEL_TRACES_SAMPLER keeps. Only
+// the ratio-based samplers read OTEL_TRACES_SAMPLER_ARG.
+func samplerRatio() float64 {
+	switch cfg.Sampler {
+	case "always_off", "parentbased_always_off":
+		return 0
+	case "traceidratio", "parentbased_traceidratio", "path_hash", "parentbased_path_hash":
+		if cfg.SamplerArg == "" {
+			return 1
+		}
+		v, err := strconv.ParseFloat(cfg.SamplerArg, 64)
+		if err != nil || v < 0 || v > 1 {
+			log.Fatalf("invalid OTEL_TRACES_SAMPLER_ARG %q: want a ratio between 0 and 1", cfg.SamplerArg)
+		}
+		return v
+	}
+	return 1
+}
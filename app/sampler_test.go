@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestPathHashSamplerIsStablePerPath(t *testing.T) {
+	s := newPathHashSampler(0.5)
+	sampled := 0
+	for i := range 50 {
+		path := fmt.Sprintf("/items/%d", i)
+		var first sdktrace.SamplingDecision
+		for j := range 10 {
+			res := s.ShouldSample(sdktrace.SamplingParameters{
+				TraceID:    trace.TraceID{byte(j), byte(i)},
+				Name:       "items",
+				Attributes: []attribute.KeyValue{attribute.String("url.path", path)},
+			})
+			if j == 0 {
+				first = res.Decision
+			} else if res.Decision != first {
+				t.Fatalf("%s: decision changed between traces", path)
+			}
+		}
+		if first == sdktrace.RecordAndSample {
+			sampled++
+		}
+	}
+	// The hash spreads paths over the range, so a ratio of 0.5 keeps some
+	// and drops others
+	if sampled == 0 || sampled == 50 {
+		t.Errorf("%d of 50 paths sampled at ratio 0.5", sampled)
+	}
+}
+
+func TestPathHashSamplerAcrossRequests(t *testing.T) {
+	app := startApp(t, "OTEL_TRACES_SAMPLER=path_hash", "OTEL_TRACES_SAMPLER_ARG=0.5")
+
+	const n = 5
+	for _, path := range []string{"/healthz", "/readyz", "/work", "/fanout", "/range"} {
+		for range n {
+			app.get(path)
+		}
+	}
+
+	perPath := map[any]int{}
+	for _, s := range app.spans() {
+		if s.Parent.SpanID == "0000000000000000" {
+			perPath[s.attr("url.path")]++
+		}
+	}
+	for path, count := range perPath {
+		if count != n {
+			t.Errorf("%v: %d of %d requests sampled, want all or none", path, count, n)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Sampling decisions since start-up, split by whether the span started a
+// trace: OTEL_TRACES_SAMPLER_ARG only applies to root spans, children of a
+// parent-based sampler just follow their parent
+var samplingCounts struct {
+	rootSampled, rootDropped   atomic.Int64
+	childSampled, childDropped atomic.Int64
+}
+
+// countingSampler counts the decisions of the sampler it wraps. RecordOnly
+// counts as dropped: the span is never exported.
+type countingSampler struct {
+	sdktrace.Sampler
+}
+
+func (s countingSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	res := s.Sampler.ShouldSample(p)
+	sampled := res.Decision == sdktrace.RecordAndSample
+	root := !trace.SpanContextFromContext(p.ParentContext).IsValid()
+	switch {
+	case root && sampled:
+		samplingCounts.rootSampled.Add(1)
+	case root:
+		samplingCounts.rootDropped.Add(1)
+	case sampled:
+		samplingCounts.childSampled.Add(1)
+	default:
+		samplingCounts.childDropped.Add(1)
+	}
+	return res
+}
+
+func (s countingSampler) Description() string {
+	return "CountingSampler{" + s.Sampler.Description() + "}"
+}
+
+type samplingCount struct {
+	Sampled        int64    `json:"sampled"`
+	Dropped        int64    `json:"dropped"`
+	EffectiveRatio *float64 `json:"effective_ratio"`
+}
+
+func newSamplingCount(sampled, dropped int64) samplingCount {
+	c := samplingCount{Sampled: sampled, Dropped: dropped}
+	if total := sampled + dropped; total > 0 {
+		ratio := float64(sampled) / float64(total)
+		c.EffectiveRatio = &ratio
+	}
+	return c
+}
+
+// samplingStatsHandler reports how many spans the sampler kept against the
+// ratio it was configured with. The effective ratio is null until a span of
+// that kind has started.
+func samplingStatsHandler(w http.ResponseWriter, r *http.Request) {
+	rootSampled, rootDropped := samplingCounts.rootSampled.Load(), samplingCounts.rootDropped.Load()
+	childSampled, childDropped := samplingCounts.childSampled.Load(), samplingCounts.childDropped.Load()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"sampler":          cfg.Sampler,
+		"configured_ratio": samplerRatio(),
+		"root_spans":       newSamplingCount(rootSampled, rootDropped),
+		"child_spans":      newSamplingCount(childSampled, childDropped),
+		"all_spans":        newSamplingCount(rootSampled+childSampled, rootDropped+childDropped),
+	})
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestSamplingStatsEffectiveRatio(t *testing.T) {
+	app := startApp(t, "OTEL_TRACES_SAMPLER=parentbased_traceidratio", "OTEL_TRACES_SAMPLER_ARG=0.3")
+	const n = 500
+	for range n / 100 {
+		app.getConcurrently(100, "/healthz")
+	}
+
+	_, body := app.get("/admin/sampling-stats")
+	var stats struct {
+		Sampler         string  `json:"sampler"`
+		ConfiguredRatio float64 `json:"configured_ratio"`
+		RootSpans       struct {
+			Sampled, Dropped int64
+			EffectiveRatio   *float64 `json:"effective_ratio"`
+		} `json:"root_spans"`
+	}
+	if err := json.Unmarshal([]byte(body), &stats); err != nil {
+		t.Fatalf("decode %q: %v", body, err)
+	}
+	if stats.Sampler != "parentbased_traceidratio" || stats.ConfiguredRatio != 0.3 {
+		t.Errorf("sampler = %s at %v, want parentbased_traceidratio at 0.3", stats.Sampler, stats.ConfiguredRatio)
+	}
+	// Every probe is a root span, and so is the stats request itself
+	root := stats.RootSpans
+	if total := root.Sampled + root.Dropped; total < n {
+		t.Errorf("counted %d root spans, want at least %d", total, n)
+	}
+	if root.EffectiveRatio == nil || math.Abs(*root.EffectiveRatio-0.3) > 0.07 {
+		t.Errorf("effective root ratio = %v, want about 0.3", root.EffectiveRatio)
+	}
+
+	// The stats agree with what was actually exported
+	app.stop()
+	exported := int64(0)
+	for _, s := range app.spans() {
+		if s.Name == "healthz" {
+			exported++
+		}
+	}
+	if exported > root.Sampled || exported < root.Sampled-1 {
+		t.Errorf("%d probe spans exported, stats say %d root spans sampled", exported, root.Sampled)
+	}
+}
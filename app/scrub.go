@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const redacted = "[REDACTED]"
+
+// scrubProcessor sits in front of an exporting span processor and masks or
+// drops attributes whose key contains one of the SCRUB_ATTRIBUTES patterns,
+// on the span and on its events (exceptions and logs carry the same keys).
+// Ended spans are read-only, so the wrapped processor is handed a view of
+// the span with the attributes filtered, and never sees the originals.
+type scrubProcessor struct {
+	sdktrace.SpanProcessor
+	patterns []string
+	remove   bool
+}
+
+// withScrubbing wraps p, or returns it unchanged when nothing is to be scrubbed
+func withScrubbing(p sdktrace.SpanProcessor) sdktrace.SpanProcessor {
+	if len(cfg.ScrubAttributes) == 0 {
+		return p
+	}
+	return scrubProcessor{p, cfg.ScrubAttributes, cfg.ScrubMode == "remove"}
+}
+
+func (p scrubProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	events := make([]sdktrace.Event, len(s.Events()))
+	for i, e := range s.Events() {
+		e.Attributes = p.scrub(e.Attributes)
+		events[i] = e
+	}
+	p.SpanProcessor.OnEnd(scrubbedSpan{s, p.scrub(s.Attributes()), events})
+}
+
+func (p scrubProcessor) scrub(attrs []attribute.KeyValue) []attribute.KeyValue {
+	out := make([]attribute.KeyValue, 0, len(attrs))
+	for _, kv := range attrs {
+		if !p.sensitive(string(kv.Key)) {
+			out = append(out, kv)
+		} else if !p.remove {
+			out = append(out, kv.Key.String(redacted))
+		}
+	}
+	return out
+}
+
+func (p scrubProcessor) sensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, pat := range p.patterns {
+		if strings.Contains(key, pat) {
+			return true
+		}
+	}
+	return false
+}
+
+type scrubbedSpan struct {
+	sdktrace.ReadOnlySpan
+	attrs  []attribute.KeyValue
+	events []sdktrace.Event
+}
+
+func (s scrubbedSpan) Attributes() []attribute.KeyValue { return s.attrs }
+func (s scrubbedSpan) Events() []sdktrace.Event         { return s.events }
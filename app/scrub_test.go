@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func scrubbedExport(t *testing.T, remove bool) tracetest.SpanStub {
+	t.Helper()
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(
+		scrubProcessor{sdktrace.NewSimpleSpanProcessor(exp), []string{"password", "authorization"}, remove},
+	))
+	defer tp.Shutdown(context.Background())
+
+	_, span := tp.Tracer("test").Start(context.Background(), "login")
+	span.SetAttributes(
+		attribute.String("user.password", "hunter2"),
+		attribute.String("http.request.header.Authorization", "Bearer abc"),
+		attribute.String("user.name", "alice"),
+	)
+	span.AddEvent("retry", trace.WithAttributes(attribute.String("db.password", "hunter2")))
+	span.End()
+
+	spans := exp.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("exported %d spans, want 1", len(spans))
+	}
+	return spans[0]
+}
+
+func attrMap(attrs []attribute.KeyValue) map[string]string {
+	m := map[string]string{}
+	for _, kv := range attrs {
+		m[string(kv.Key)] = kv.Value.Emit()
+	}
+	return m
+}
+
+func TestScrubMasksSensitiveAttributes(t *testing.T) {
+	s := scrubbedExport(t, false)
+	attrs := attrMap(s.Attributes)
+	for _, key := range []string{"user.password", "http.request.header.Authorization"} {
+		if attrs[key] != redacted {
+			t.Errorf("%s = %q, want %q", key, attrs[key], redacted)
+		}
+	}
+	if attrs["user.name"] != "alice" {
+		t.Errorf("user.name = %q, want it left alone", attrs["user.name"])
+	}
+	if v := attrMap(s.Events[0].Attributes)["db.password"]; v != redacted {
+		t.Errorf("event db.password = %q, want %q", v, redacted)
+	}
+}
+
+func TestScrubRemovesSensitiveAttributes(t *testing.T) {
+	s := scrubbedExport(t, true)
+	attrs := attrMap(s.Attributes)
+	for _, key := range []string{"user.password", "http.request.header.Authorization"} {
+		if v, ok := attrs[key]; ok {
+			t.Errorf("%s = %q exported, want it removed", key, v)
+		}
+	}
+	if len(s.Events[0].Attributes) != 0 {
+		t.Errorf("event attributes = %v, want the password removed", s.Events[0].Attributes)
+	}
+}
+
+func TestScrubCapturedHeader(t *testing.T) {
+	app := startApp(t, "CAPTURE_REQUEST_HEADERS=Authorization")
+	app.get("/healthz", "Authorization", "Bearer secret-token")
+
+	span := app.spansNamed("healthz", 1)[0]
+	v := fmt.Sprint(span.attr("http.request.header.authorization"))
+	if !strings.Contains(v, redacted) {
+		t.Errorf("http.request.header.authorization = %s, want %s", v, redacted)
+	}
+	for _, line := range app.output() {
+		if strings.Contains(line, "secret-token") {
+			t.Errorf("the token appears in the output: %s", line)
+		}
+	}
+}
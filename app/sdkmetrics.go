@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// Instrumentation scope the SDK uses for its own experimental metrics, which
+// it only records when OTEL_GO_X_OBSERVABILITY=true
+const sdkObservScope = "go.opentelemetry.io/otel/sdk/trace/internal/observ"
+
+// Reader attached to the MeterProvider so the SDK's self-diagnostics can be
+// pulled on every Prometheus scrape
+var sdkReader = sdkmetric.NewManualReader()
+
+var (
+	bspQueueSizeDesc = prometheus.NewDesc("otel_bsp_queue_size",
+		"Spans currently queued in the OTel batch span processor",
+		[]string{"processor"}, nil)
+	bspQueueCapacityDesc = prometheus.NewDesc("otel_bsp_queue_capacity",
+		"Maximum number of spans the OTel batch span processor can queue",
+		[]string{"processor"}, nil)
+	bspProcessedDesc = prometheus.NewDesc("otel_bsp_processed_spans_total",
+		"Spans handed to the exporter by the OTel batch span processor",
+		[]string{"processor"}, nil)
+	bspDroppedDesc = prometheus.NewDesc("otel_bsp_dropped_spans_total",
+		"Spans dropped by the OTel batch span processor because its queue was full",
+		[]string{"processor"}, nil)
+)
+
+// bspCollector re-exposes the SDK's batch span processor metrics in
+// Prometheus form
+type bspCollector struct{}
+
+func (bspCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- bspQueueSizeDesc
+	ch <- bspQueueCapacityDesc
+	ch <- bspProcessedDesc
+	ch <- bspDroppedDesc
+}
+
+func (bspCollector) Collect(ch chan<- prometheus.Metric) {
+	var rm metricdata.ResourceMetrics
+	if err := sdkReader.Collect(context.Background(), &rm); err != nil {
+		log.Printf("failed to collect SDK metrics: %v", err)
+		return
+	}
+
+	for _, sm := range rm.ScopeMetrics {
+		if sm.Scope.Name != sdkObservScope {
+			continue
+		}
+		for _, m := range sm.Metrics {
+			switch m.Name {
+			case "otel.sdk.processor.span.queue.size":
+				emitGauge(ch, bspQueueSizeDesc, m.Data)
+			case "otel.sdk.processor.span.queue.capacity":
+				emitGauge(ch, bspQueueCapacityDesc, m.Data)
+			case "otel.sdk.processor.span.processed":
+				emitProcessed(ch, m.Data)
+			}
+		}
+	}
+}
+
+// Queue size and capacity are recorded as up-down counters, which are
+// gauges in Prometheus terms
+func emitGauge(ch chan<- prometheus.Metric, desc *prometheus.Desc, data metricdata.Aggregation) {
+	g, ok := data.(metricdata.Sum[int64])
+	if !ok {
+		return
+	}
+	for _, dp := range g.DataPoints {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue,
+			float64(dp.Value), processorName(dp.Attributes))
+	}
+}
+
+// The SDK records drops as processed spans with error.type=queue_full
+func emitProcessed(ch chan<- prometheus.Metric, data metricdata.Aggregation) {
+	s, ok := data.(metricdata.Sum[int64])
+	if !ok {
+		return
+	}
+	for _, dp := range s.DataPoints {
+		desc := bspProcessedDesc
+		if v, ok := dp.Attributes.Value("error.type"); ok && v.AsString() == "queue_full" {
+			desc = bspDroppedDesc
+		}
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue,
+			float64(dp.Value), processorName(dp.Attributes))
+	}
+}
+
+func processorName(attrs attribute.Set) string {
+	v, _ := attrs.Value("otel.component.name")
+	return v.AsString()
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestBatchProcessorQueueSize(t *testing.T) {
+	// The first span goes out alone and its export doesn't return until the
+	// test ends, so everything after it stays queued
+	collector := startCollector(t)
+	collector.hold = make(chan struct{})
+	app := startApp(t,
+		"OTLP_ENDPOINTS="+collector.addr,
+		"OTEL_GO_X_OBSERVABILITY=true",
+		"OTEL_BSP_MAX_EXPORT_BATCH_SIZE=1",
+		"OTEL_BSP_MAX_QUEUE_SIZE=100",
+	)
+	// Let the export through before the app is stopped
+	t.Cleanup(func() { close(collector.hold) })
+
+	for range 5 {
+		if resp, _ := app.get("/healthz"); resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d, want 200", resp.StatusCode)
+		}
+	}
+
+	// Everything but the span stuck in export
+	app.waitFor("4 spans queued", func() bool {
+		v, _ := seriesValue(app.scrape(), "otel_bsp_queue_size")
+		return v == 4
+	})
+	if v, _ := seriesValue(app.scrape(), "otel_bsp_queue_capacity"); v != 100 {
+		t.Errorf("otel_bsp_queue_capacity = %v, want 100", v)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"net"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The 431 for oversized headers is written by net/http itself before any
+// handler runs, so it's counted at the connection level instead
+var oversizedHeaderRejections = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "http_oversized_header_rejections_total",
+		Help: "Requests rejected with 431 because their headers exceeded MAX_HEADER_BYTES",
+	},
+)
+
+var status431 = []byte("HTTP/1.1 431 ")
+
+type headerLimitListener struct {
+	net.Listener
+}
+
+func (l headerLimitListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return headerLimitConn{conn}, nil
+}
+
+type headerLimitConn struct {
+	net.Conn
+}
+
+func (c headerLimitConn) Write(b []byte) (int, error) {
+	if bytes.HasPrefix(b, status431) {
+		oversizedHeaderRejections.Inc()
+	}
+	return c.Conn.Write(b)
+}
+
+// CloseWrite lets net/http half-close the connection after an error
+// response, so the client reads the 431 instead of a connection reset
+func (c headerLimitConn) CloseWrite() error {
+	if cw, ok := c.Conn.(interface{ CloseWrite() error }); ok {
+		return cw.CloseWrite()
+	}
+	return nil
+}
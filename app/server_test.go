@@ -0,0 +1,76 @@
+package main
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOversizedHeadersRejected(t *testing.T) {
+	app := startApp(t, "MAX_HEADER_BYTES=1024")
+
+	// net/http allows 4KiB of slack on top of MaxHeaderBytes
+	resp, _ := app.get("/work", "X-Padding", strings.Repeat("a", 6<<10))
+	if resp.StatusCode != http.StatusRequestHeaderFieldsTooLarge {
+		t.Fatalf("status = %d, want 431", resp.StatusCode)
+	}
+	if v := app.metricValue("http_oversized_header_rejections_total"); v != 1 {
+		t.Errorf("http_oversized_header_rejections_total = %v, want 1", v)
+	}
+
+	if resp, _ := app.get("/healthz", "X-Padding", "small"); resp.StatusCode != http.StatusOK {
+		t.Errorf("small headers: status = %d, want 200", resp.StatusCode)
+	}
+	if v := app.metricValue("http_oversized_header_rejections_total"); v != 1 {
+		t.Errorf("counter after a normal request = %v, want still 1", v)
+	}
+}
+
+func TestReadHeaderTimeoutCutsOffSlowClient(t *testing.T) {
+	app := startApp(t, "HTTP_READ_HEADER_TIMEOUT=200ms")
+
+	conn, err := net.Dial("tcp", app.addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	// Start a request and never finish its headers
+	if _, err := io.WriteString(conn, "GET /healthz HTTP/1.1\r\nHost: test\r\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	io.Copy(io.Discard, conn)
+	if elapsed := time.Since(start); elapsed >= 5*time.Second {
+		t.Fatal("connection still open after 5s")
+	} else if elapsed < 150*time.Millisecond {
+		t.Errorf("connection closed after %s, before the 200ms header timeout", elapsed)
+	}
+}
+
+func TestListenTCP4Only(t *testing.T) {
+	if ln, err := net.Listen("tcp6", "[::1]:0"); err != nil {
+		t.Skipf("no IPv6 loopback: %v", err)
+	} else {
+		ln.Close()
+	}
+	app := startApp(t, "LISTEN_NETWORK=tcp4", "LISTEN_ADDR=:0")
+
+	_, port, err := net.SplitHostPort(app.addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn, err := net.Dial("tcp4", "127.0.0.1:"+port); err != nil {
+		t.Errorf("IPv4 dial failed: %v", err)
+	} else {
+		conn.Close()
+	}
+	if conn, err := net.DialTimeout("tcp6", "[::1]:"+port, time.Second); err == nil {
+		conn.Close()
+		t.Error("IPv6 dial succeeded with LISTEN_NETWORK=tcp4")
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import "testing"
+
+func TestPerSignalEndpoints(t *testing.T) {
+	traces, metrics, logs := startCollector(t), startCollector(t), startCollector(t)
+	app := startApp(t,
+		"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT=http://"+traces.addr,
+		"OTEL_EXPORTER_OTLP_METRICS_ENDPOINT="+metrics.addr,
+		"OTEL_EXPORTER_OTLP_LOGS_ENDPOINT="+logs.addr,
+		"OTEL_LOGS_EXPORTER=otlp",
+		"OTEL_BSP_SCHEDULE_DELAY=50",
+		"OTEL_METRIC_EXPORT_INTERVAL=200",
+	)
+	app.get("/work")
+
+	app.waitFor("each signal at its collector", func() bool {
+		return len(traces.resourceSpans()) > 0 && len(metrics.resourceMetrics()) > 0 && len(logs.resourceLogs()) > 0
+	})
+	for name, c := range map[string]*fakeCollector{"traces": traces, "metrics": metrics, "logs": logs} {
+		if name != "traces" && len(c.resourceSpans()) > 0 {
+			t.Errorf("the %s collector received spans", name)
+		}
+		if name != "metrics" && len(c.resourceMetrics()) > 0 {
+			t.Errorf("the %s collector received metrics", name)
+		}
+		if name != "logs" && len(c.resourceLogs()) > 0 {
+			t.Errorf("the %s collector received logs", name)
+		}
+	}
+	// The common endpoint is overridden for everything
+	c := app.collector
+	if len(c.resourceSpans())+len(c.resourceMetrics())+len(c.resourceLogs()) > 0 {
+		t.Error("the common OTLP_ENDPOINTS collector received telemetry")
+	}
+}
+
+func TestPerSignalEndpointFallsBack(t *testing.T) {
+	traces := startCollector(t)
+	app := startApp(t,
+		"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT="+traces.addr,
+		"OTEL_BSP_SCHEDULE_DELAY=50",
+		"OTEL_METRIC_EXPORT_INTERVAL=200",
+	)
+	app.get("/work")
+
+	app.waitFor("spans at the traces collector and metrics at the common one", func() bool {
+		return len(traces.resourceSpans()) > 0 && len(app.collector.resourceMetrics()) > 0
+	})
+	if len(app.collector.resourceSpans()) > 0 {
+		t.Error("spans went to the common endpoint despite OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	}
+	if len(traces.resourceMetrics()) > 0 {
+		t.Error("metrics went to the traces endpoint")
+	}
+}
+
+func TestHTTPSEndpointRejected(t *testing.T) {
+	app := launchApp(t, "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT=https://collector.example:4317")
+	if code := app.wait(); code == 0 {
+		t.Error("app started with an https:// endpoint it would export to in plaintext")
+	}
+	app.lineContaining(`OTLP endpoint "https://collector.example:4317": TLS isn't supported`)
+}
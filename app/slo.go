@@ -0,0 +1,86 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var sloRequests = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "slo_requests_total",
+		Help: "/work requests counted against the availability SLO",
+	},
+)
+
+var sloGoodRequests = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "slo_good_requests_total",
+		Help: "/work requests that met the availability SLO (no 5xx)",
+	},
+)
+
+// Slots the burn rate window is split into; the window slides one slot at
+// a time
+const sloSlots = 10
+
+// Good and total counts per slot, each stamped with the slot's index since
+// the epoch so slots left over from an earlier pass of the ring are ignored
+var sloWindow struct {
+	sync.Mutex
+	epoch [sloSlots]int64
+	good  [sloSlots]int
+	total [sloSlots]int
+}
+
+func sloSlot() time.Duration { return cfg.SLOBurnWindow / sloSlots }
+
+// recordSLO counts one /work request against the SLO
+func recordSLO(good bool) {
+	sloRequests.Inc()
+	if good {
+		sloGoodRequests.Inc()
+	}
+
+	idx := time.Now().UnixNano() / int64(sloSlot())
+	sloWindow.Lock()
+	defer sloWindow.Unlock()
+	i := idx % sloSlots
+	if sloWindow.epoch[i] != idx {
+		sloWindow.epoch[i], sloWindow.good[i], sloWindow.total[i] = idx, 0, 0
+	}
+	sloWindow.total[i]++
+	if good {
+		sloWindow.good[i]++
+	}
+}
+
+// errorBudgetBurnRate is the error ratio over the last SLO_BURN_WINDOW
+// divided by the error budget (1 - SLO_TARGET): 1 spends the budget exactly
+// over the SLO period, 14.4 on a 1h window is the classic fast-burn page
+func errorBudgetBurnRate() float64 {
+	idx := time.Now().UnixNano() / int64(sloSlot())
+	sloWindow.Lock()
+	var good, total int
+	for i := range sloSlots {
+		if idx-sloWindow.epoch[i] < sloSlots {
+			good += sloWindow.good[i]
+			total += sloWindow.total[i]
+		}
+	}
+	sloWindow.Unlock()
+	if total == 0 {
+		return 0
+	}
+	errorRatio := float64(total-good) / float64(total)
+	return errorRatio / (1 - cfg.SLOTarget)
+}
+
+var errorBudgetBurnRateGauge = prometheus.NewGaugeFunc(
+	prometheus.GaugeOpts{
+		Name: "error_budget_burn_rate",
+		Help: "Error ratio of /work over SLO_BURN_WINDOW divided by the error budget (1 - SLO_TARGET)",
+	},
+	errorBudgetBurnRate,
+)
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestErrorBudgetBurnRate(t *testing.T) {
+	app := startApp(t, "SLO_TARGET=0.9", "FAILURE_RATE=0.5", "SLO_BURN_WINDOW=3s")
+	app.getConcurrently(20, "/work")
+
+	f := app.scrape()
+	total, _ := seriesValue(f, "slo_requests_total")
+	good, _ := seriesValue(f, "slo_good_requests_total")
+	burn, _ := seriesValue(f, "error_budget_burn_rate")
+	if total != 20 || good == 0 || good == total {
+		t.Fatalf("good/total = %v/%v, want a mix of outcomes over 20 requests", good, total)
+	}
+	// The error ratio against the 10% budget
+	want := (total - good) / total / 0.1
+	if math.Abs(burn-want) > 1e-9 {
+		t.Errorf("error_budget_burn_rate = %v, want %v for %v errors in %v", burn, want, total-good, total)
+	}
+
+	// Once the window has moved past the requests, nothing is burning
+	time.Sleep(3500 * time.Millisecond)
+	if v := app.metricValue("error_budget_burn_rate"); v != 0 {
+		t.Errorf("burn rate after the window = %v, want 0", v)
+	}
+}
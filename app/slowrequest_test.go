@@ -0,0 +1,31 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSlowRequestWarning(t *testing.T) {
+	// ap-south makes every /work take at least 150ms; /healthz is instant
+	app := startApp(t, "SLOW_REQUEST_MS=100", "REGION=ap-south")
+	if resp, _ := app.get("/work"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want a slow success", resp.StatusCode)
+	}
+	app.get("/healthz")
+
+	span := app.spansNamed("work", 1)[0]
+	slow := app.logsWithMsg("slow request")
+	if len(slow) != 1 {
+		t.Fatalf("got %d slow request warnings, want 1", len(slow))
+	}
+	rec := slow[0]
+	if rec["level"] != "WARN" || rec["path"] != "/work" || rec["status"] != float64(200) {
+		t.Errorf("warning = %v, want WARN for the 200 from /work", rec)
+	}
+	if rec["trace_id"] != span.SpanContext.TraceID {
+		t.Errorf("trace_id = %v, want %s", rec["trace_id"], span.SpanContext.TraceID)
+	}
+	if ms, _ := rec["latency_ms"].(float64); ms < 150 {
+		t.Errorf("latency_ms = %v, want at least 150", rec["latency_ms"])
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"sync"
+
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// lastSpanWriter keeps the most recent span written by the stdout exporter,
+// which encodes exactly one span per Write
+type lastSpanWriter struct {
+	mu   sync.Mutex
+	last []byte
+	tee  io.Writer
+}
+
+var lastSpan = &lastSpanWriter{}
+
+func (w *lastSpanWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	w.last = append(w.last[:0], p...)
+	w.mu.Unlock()
+	if w.tee != nil {
+		return w.tee.Write(p)
+	}
+	return len(p), nil
+}
+
+// newSpanJSONExporter builds the JSON exporter that runs next to OTLP so
+// learners can compare what goes on the wire. mode is "memory" or "stdout".
+func newSpanJSONExporter(mode string) sdktrace.SpanExporter {
+	if mode == "stdout" {
+		lastSpan.tee = logOutput
+	}
+	exp, err := stdouttrace.New(stdouttrace.WithWriter(lastSpan))
+	if err != nil {
+		log.Fatalf("failed to create JSON span exporter: %v", err)
+	}
+	return exp
+}
+
+func lastSpanJSONHandler(w http.ResponseWriter, r *http.Request) {
+	lastSpan.mu.Lock()
+	defer lastSpan.mu.Unlock()
+	if len(lastSpan.last) == 0 {
+		http.Error(w, "no span exported yet (is SPAN_JSON_EXPORT set?)", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(lastSpan.last)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestLastSpanJSON(t *testing.T) {
+	app := startApp(t, "SPAN_JSON_EXPORT=memory")
+
+	const traceID, parentID = "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+	app.get("/healthz", "traceparent", "00-"+traceID+"-"+parentID+"-01")
+	resp, body := app.get("/admin/last-span-json")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	var s exportedSpan
+	if err := json.Unmarshal([]byte(body), &s); err != nil {
+		t.Fatalf("bad JSON %q: %v", body, err)
+	}
+	if s.Name != "healthz" || s.SpanContext.TraceID != traceID || s.Parent.SpanID != parentID {
+		t.Errorf("last span = %s in trace %s under %s, want healthz in %s under %s",
+			s.Name, s.SpanContext.TraceID, s.Parent.SpanID, traceID, parentID)
+	}
+	if v := s.attr("http.request.method"); v != "GET" {
+		t.Errorf("http.request.method = %v, want GET", v)
+	}
+	if s.StartTime.IsZero() || s.EndTime.Before(s.StartTime) {
+		t.Errorf("bad timestamps %v - %v", s.StartTime, s.EndTime)
+	}
+	// memory mode keeps spans off stdout
+	if n := len(app.spans()); n != 0 {
+		t.Errorf("%d spans written to stdout in memory mode", n)
+	}
+}
+
+func TestLastSpanJSONOff(t *testing.T) {
+	app := startApp(t, "SPAN_JSON_EXPORT=off")
+
+	app.get("/healthz")
+	if resp, _ := app.get("/admin/last-span-json"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d with SPAN_JSON_EXPORT=off, want 404", resp.StatusCode)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+// Span kinds as the stdout exporter writes them
+const (
+	spanKindInternal = 1
+	spanKindServer   = 2
+	spanKindClient   = 3
+)
+
+func TestSpanKinds(t *testing.T) {
+	stub := startStub(t, http.StatusOK)
+	app := startApp(t, "DOWNSTREAM_URL="+stub.URL)
+	app.get("/work")
+
+	server := app.spansNamed("work", 1)[0]
+	if server.SpanKind != spanKindServer {
+		t.Errorf("work server span kind = %d, want server", server.SpanKind)
+	}
+	kinds := map[string]int{}
+	for _, s := range app.children(server) {
+		kinds[s.Name] = s.SpanKind
+	}
+	if k, ok := kinds["simulate_work"]; !ok || k != spanKindInternal {
+		t.Errorf("simulate_work kind = %d (present %v), want internal", k, ok)
+	}
+	if k, ok := kinds["GET"]; !ok || k != spanKindClient {
+		t.Errorf("downstream GET kind = %d (present %v), want client", k, ok)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// rootSpanDurations is a SpanProcessor that keeps the durations of the last
+// few local root spans (a request's server span, or a background job), so
+// percentiles can be read straight off a gauge without histogram_quantile
+type rootSpanDurations struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+	full    bool
+}
+
+// Nil unless SPAN_PERCENTILE_SAMPLES is set
+var rootSpans *rootSpanDurations
+
+func newRootSpanDurations(size int) *rootSpanDurations {
+	return &rootSpanDurations{samples: make([]time.Duration, size)}
+}
+
+func (r *rootSpanDurations) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+
+func (r *rootSpanDurations) OnEnd(s sdktrace.ReadOnlySpan) {
+	if s.Parent().IsValid() && !s.Parent().IsRemote() {
+		return
+	}
+	r.observe(s.EndTime().Sub(s.StartTime()))
+}
+
+func (r *rootSpanDurations) Shutdown(context.Context) error   { return nil }
+func (r *rootSpanDurations) ForceFlush(context.Context) error { return nil }
+
+func (r *rootSpanDurations) observe(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.samples[r.next] = d
+	r.next = (r.next + 1) % len(r.samples)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// percentile returns the nearest-rank q-th percentile of the kept samples in
+// seconds, or NaN before any root span ended
+func (r *rootSpanDurations) percentile(q float64) float64 {
+	r.mu.Lock()
+	n := r.next
+	if r.full {
+		n = len(r.samples)
+	}
+	sorted := slices.Clone(r.samples[:n])
+	r.mu.Unlock()
+
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	slices.Sort(sorted)
+	rank := int(math.Ceil(q/100*float64(len(sorted)))) - 1
+	return sorted[max(rank, 0)].Seconds()
+}
+
+// gauges exposes span_duration_p50_seconds, _p95_ and _p99_, computed on
+// each scrape
+func (r *rootSpanDurations) gauges() []prometheus.Collector {
+	var gauges []prometheus.Collector
+	for _, q := range []float64{50, 95, 99} {
+		gauges = append(gauges, prometheus.NewGaugeFunc(
+			prometheus.GaugeOpts{
+				Name: fmt.Sprintf("span_duration_p%g_seconds", q),
+				Help: fmt.Sprintf("%gth percentile duration of the last SPAN_PERCENTILE_SAMPLES root spans, NaN before the first one", q),
+			},
+			func() float64 { return r.percentile(q) },
+		))
+	}
+	return gauges
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSpanDurationPercentiles(t *testing.T) {
+	r := newRootSpanDurations(100)
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(r))
+	defer tp.Shutdown(context.Background())
+	tracer := tp.Tracer("test")
+
+	if p := r.percentile(50); !math.IsNaN(p) {
+		t.Errorf("p50 before any span = %v, want NaN", p)
+	}
+
+	// Root spans of 1ms to 100ms, each with a long child that must not count
+	start := time.Now()
+	for i := 1; i <= 100; i++ {
+		ctx, root := tracer.Start(context.Background(), "root", trace.WithTimestamp(start))
+		_, child := tracer.Start(ctx, "child", trace.WithTimestamp(start))
+		child.End(trace.WithTimestamp(start.Add(time.Hour)))
+		root.End(trace.WithTimestamp(start.Add(time.Duration(i) * time.Millisecond)))
+	}
+
+	gauges := r.gauges()
+	for i, want := range []float64{0.050, 0.095, 0.099} {
+		if got := testutil.ToFloat64(gauges[i]); math.Abs(got-want) > 1e-9 {
+			t.Errorf("gauge %d = %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestSpanDurationPercentilesRolling(t *testing.T) {
+	r := newRootSpanDurations(10)
+	for i := 1; i <= 20; i++ {
+		r.observe(time.Duration(i) * time.Second)
+	}
+	// Only the last ten, 11s to 20s, are kept
+	if p := r.percentile(50); p != 15 {
+		t.Errorf("p50 = %v, want 15", p)
+	}
+	if p := r.percentile(99); p != 20 {
+		t.Errorf("p99 = %v, want 20", p)
+	}
+}
+
+func TestSpanDurationPercentilesScraped(t *testing.T) {
+	// ap-south and a cache that always hits put every /work between 150ms
+	// and 550ms
+	app := startApp(t, "SPAN_PERCENTILE_SAMPLES=100", "REGION=ap-south", "CACHE_HIT_RATIO=1")
+	app.getConcurrently(10, "/work")
+
+	p50 := app.metricValue("span_duration_p50_seconds")
+	p99 := app.metricValue("span_duration_p99_seconds")
+	if p50 < 0.15 || p99 > 0.6 || p50 > p99 {
+		t.Errorf("p50 = %v, p99 = %v, want 0.15 <= p50 <= p99 <= 0.6", p50, p99)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// spanDetail controls how many child spans a request produces, trading
+// trace detail for overhead under load
+type spanDetail int
+
+const (
+	// Only the server span created by otelhttp
+	spanDetailNone spanDetail = iota
+	// Server span plus client spans for calls that leave the process
+	spanDetailBasic
+	// Everything, including a span per internal work phase
+	spanDetailFull
+)
+
+func (d spanDetail) String() string {
+	switch d {
+	case spanDetailNone:
+		return "none"
+	case spanDetailBasic:
+		return "basic"
+	default:
+		return "full"
+	}
+}
+
+func parseSpanDetail(s string) (spanDetail, error) {
+	switch s {
+	case "none":
+		return spanDetailNone, nil
+	case "basic":
+		return spanDetailBasic, nil
+	case "full":
+		return spanDetailFull, nil
+	}
+	return 0, fmt.Errorf("must be one of none, basic, full")
+}
+
+// noopSpan is handed out when SPAN_DETAIL suppresses a span, so callers can
+// End it unconditionally
+var noopSpan = trace.SpanFromContext(context.Background())
+
+// startPhase starts a child span for an internal work phase, or returns the
+// context unchanged and a no-op span when the detail level is below full.
+// The kind is set explicitly since service graphs are built from span kinds.
+func startPhase(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if cfg.SpanDetail < spanDetailFull {
+		return ctx, noopSpan
+	}
+	opts = append(opts, trace.WithSpanKind(trace.SpanKindInternal))
+	return otel.Tracer("app").Start(ctx, name, opts...)
+}
+
+// startClient is startPhase for spans representing outbound calls, which are
+// kept at the basic level too
+func startClient(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if cfg.SpanDetail < spanDetailBasic {
+		return ctx, noopSpan
+	}
+	opts = append(opts, trace.WithSpanKind(trace.SpanKindClient))
+	return otel.Tracer("app").Start(ctx, name, opts...)
+}
+
+// startBackground starts a root span for one iteration of a background task,
+// so pollers and watchers show up as their own traces instead of hanging off
+// whatever request happened to be in flight. Off unless TRACE_BACKGROUND_TASKS.
+func startBackground(task string) (context.Context, trace.Span) {
+	ctx := context.Background()
+	if !cfg.TraceBackgroundTasks {
+		return ctx, noopSpan
+	}
+	return otel.Tracer("app").Start(ctx, "background "+task,
+		trace.WithNewRoot(),
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(attribute.String("background.task", task)),
+	)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSpanDetailNone(t *testing.T) {
+	app := startApp(t, "SPAN_DETAIL=none")
+
+	// A failed request has the same spans, so its status doesn't matter
+	app.get("/work")
+	root := app.spansNamed("work", 1)[0]
+	if children := app.children(root); len(children) != 0 {
+		t.Errorf("got %d child spans with SPAN_DETAIL=none, want none", len(children))
+	}
+}
+
+func TestSpanDetailFull(t *testing.T) {
+	app := startApp(t, "SPAN_DETAIL=full")
+
+	app.get("/work")
+	root := app.spansNamed("work", 1)[0]
+	names := map[string]bool{}
+	for _, c := range app.children(root) {
+		names[c.Name] = true
+	}
+	for _, want := range []string{"simulate_work", "db_cache_lookup"} {
+		if !names[want] {
+			t.Errorf("no %s child span with SPAN_DETAIL=full; got %v", want, names)
+		}
+	}
+}
+
+func TestPhaseEventTimeline(t *testing.T) {
+	app := startApp(t, "SPAN_DETAIL=basic")
+
+	app.get("/work")
+	root := app.spansNamed("work", 1)[0]
+	var names []string
+	prev := root.StartTime
+	for _, e := range root.Events {
+		names = append(names, e.Name)
+		if e.Time.Before(prev) || e.Time.After(root.EndTime) {
+			t.Errorf("event %s at %v is out of order or outside the span (%v - %v)", e.Name, e.Time, root.StartTime, root.EndTime)
+		}
+		prev = e.Time
+	}
+	want := []string{"work_start", "work_end", "cache_start", "cache_end"}
+	if !slices.Equal(names, want) {
+		t.Errorf("events = %v, want %v", names, want)
+	}
+}
+
+func TestBackgroundTaskRootSpan(t *testing.T) {
+	app := startApp(t, "TRACE_BACKGROUND_TASKS=true", "COLLECTOR_HEALTH_INTERVAL=100ms")
+
+	spans := app.spansNamed("background collector_health", 2)
+	for _, s := range spans {
+		if s.Parent.SpanID != "0000000000000000" {
+			t.Errorf("background span has parent %s, want a root span", s.Parent.SpanID)
+		}
+		if v := s.attr("background.task"); v != "collector_health" {
+			t.Errorf("background.task = %v, want collector_health", v)
+		}
+	}
+	if spans[0].SpanContext.TraceID == spans[1].SpanContext.TraceID {
+		t.Error("two iterations share a trace, want a root per iteration")
+	}
+}
+
+func TestBackgroundTasksUntracedByDefault(t *testing.T) {
+	app := startApp(t, "COLLECTOR_HEALTH_INTERVAL=50ms")
+
+	app.get("/healthz")
+	app.spansNamed("healthz", 1)
+	for _, s := range app.spans() {
+		if strings.HasPrefix(s.Name, "background ") {
+			t.Errorf("exported %q without TRACE_BACKGROUND_TASKS", s.Name)
+		}
+	}
+}
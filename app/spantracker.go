@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var longRunningSpans = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "long_running_spans_total",
+		Help: "Spans that were still open after MAX_SPAN_DURATION, usually a missing End()",
+	},
+)
+
+// spanTracker is a SpanProcessor that keeps track of every span that has
+// started but not yet ended
+type spanTracker struct {
+	mu     sync.Mutex
+	active map[trace.SpanID]*trackedSpan
+}
+
+type trackedSpan struct {
+	span    sdktrace.ReadWriteSpan
+	flagged bool
+}
+
+var activeSpans = &spanTracker{active: map[trace.SpanID]*trackedSpan{}}
+
+func (t *spanTracker) OnStart(_ context.Context, s sdktrace.ReadWriteSpan) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.active[s.SpanContext().SpanID()] = &trackedSpan{span: s}
+}
+
+func (t *spanTracker) OnEnd(s sdktrace.ReadOnlySpan) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.active, s.SpanContext().SpanID())
+}
+
+func (t *spanTracker) Shutdown(context.Context) error   { return nil }
+func (t *spanTracker) ForceFlush(context.Context) error { return nil }
+
+// watch periodically reports spans open for longer than max. A span can't be
+// ended on its owner's behalf, so each leak is only logged and counted once.
+func (t *spanTracker) watch(max time.Duration) {
+	backgroundPool.every(max/2, func() {
+		_, span := startBackground("span_watch")
+		t.flagLongRunning(max)
+		span.End()
+	})
+}
+
+func (t *spanTracker) flagLongRunning(max time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, ts := range t.active {
+		age := time.Since(ts.span.StartTime())
+		if ts.flagged || age < max {
+			continue
+		}
+		ts.flagged = true
+		longRunningSpans.Inc()
+		logger.Warn("span exceeded max duration",
+			"span_name", ts.span.Name(),
+			"trace_id", ts.span.SpanContext().TraceID().String(),
+			"span_id", ts.span.SpanContext().SpanID().String(),
+			"age_ms", age.Milliseconds(),
+		)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestLongRunningSpanFlaggedOnce(t *testing.T) {
+	var logs bytes.Buffer
+	defer func(l *slog.Logger) { logger = l }(logger)
+	logger = slog.New(slog.NewJSONHandler(&logs, nil))
+
+	tracker := &spanTracker{active: map[trace.SpanID]*trackedSpan{}}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(tracker))
+	defer tp.Shutdown(context.Background())
+	tracer := tp.Tracer("test")
+	_, old := tracer.Start(context.Background(), "leaky", trace.WithTimestamp(time.Now().Add(-time.Hour)))
+	_, fresh := tracer.Start(context.Background(), "fresh")
+	before := testutil.ToFloat64(longRunningSpans)
+
+	tracker.flagLongRunning(time.Minute)
+	tracker.flagLongRunning(time.Minute)
+	if got := testutil.ToFloat64(longRunningSpans) - before; got != 1 {
+		t.Errorf("long_running_spans_total grew by %v, want 1", got)
+	}
+	if n := strings.Count(logs.String(), "span exceeded max duration"); n != 1 {
+		t.Errorf("got %d warnings, want 1:\n%s", n, logs.String())
+	}
+	if !strings.Contains(logs.String(), `"span_name":"leaky"`) {
+		t.Errorf("warning doesn't name the span:\n%s", logs.String())
+	}
+
+	old.End()
+	fresh.End()
+	if len(tracker.active) != 0 {
+		t.Errorf("%d spans still tracked after ending", len(tracker.active))
+	}
+}
+
+// REGION=ap-south keeps every /work span open for at least 150ms
+func TestMaxSpanDurationWatch(t *testing.T) {
+	app := startApp(t, "MAX_SPAN_DURATION=40ms", "REGION=ap-south")
+
+	app.get("/work")
+	var named bool
+	for _, rec := range app.logsWithMsg("span exceeded max duration") {
+		named = named || rec["span_name"] == "work"
+	}
+	if !named {
+		t.Error("no warning for the work span")
+	}
+	if v := app.metricValue("long_running_spans_total"); v < 1 {
+		t.Errorf("long_running_spans_total = %v, want at least 1", v)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// nginx's non-standard status for "client closed request"
+const statusClientClosedRequest = 499
+
+// Cause attached to the request context when our own deadline fires, so it
+// can be told apart from a plain context.Canceled from a client disconnect
+var errServerTimeout = errors.New("server request timeout exceeded")
+
+var serverTimeouts = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "http_server_timeouts_total",
+		Help: "Requests aborted because they exceeded the server-enforced timeout",
+	},
+	[]string{"route"},
+)
+
+var clientCancellations = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "http_client_cancellations_total",
+		Help: "Requests aborted because the client went away before a response was written",
+	},
+	[]string{"route"},
+)
+
+// withTimeout bounds the request context to the route's timeout: its
+// ROUTE_TIMEOUTS entry, or REQUEST_TIMEOUT. Handlers are expected to watch
+// the context and call abortRequest once it's done.
+func withTimeout(next http.Handler, route string) http.Handler {
+	d, ok := cfg.RouteTimeouts[route]
+	if !ok {
+		d = cfg.RequestTimeout
+	}
+	if d <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeoutCause(r.Context(), d, errServerTimeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// sleepCtx is time.Sleep that returns early with the context's error
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// abortRequest responds to a request whose context ended early, recording
+// whether it was our timeout (504) or the client disconnecting (499).
+func abortRequest(ctx context.Context, w http.ResponseWriter, route string, log *slog.Logger) int {
+	reason, status := "client_canceled", statusClientClosedRequest
+	if errors.Is(context.Cause(ctx), errServerTimeout) {
+		reason, status = "server_timeout", http.StatusGatewayTimeout
+		serverTimeouts.WithLabelValues(route).Inc()
+	} else {
+		clientCancellations.WithLabelValues(route).Inc()
+	}
+
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.String("request.abort_reason", reason))
+	span.SetStatus(codes.Error, reason)
+
+	log.Warn("request aborted",
+		"reason", reason,
+		"status", status,
+	)
+	// Nobody reads this in the client_canceled case, but it keeps the
+	// recorded status consistent with what we report
+	http.Error(w, reason, status)
+	return status
+}
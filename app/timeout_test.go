@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// REGION=ap-south adds a fixed 150ms to every /work request
+func TestServerTimeout(t *testing.T) {
+	app := startApp(t, "REGION=ap-south", "REQUEST_TIMEOUT=50ms")
+
+	if resp, _ := app.get("/work"); resp.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want 504", resp.StatusCode)
+	}
+	span := app.spansNamed("work", 1)[0]
+	if v := span.attr("request.abort_reason"); v != "server_timeout" {
+		t.Errorf("request.abort_reason = %v, want server_timeout", v)
+	}
+	if v := app.metricValue("http_server_timeouts_total", "route", "work"); v != 1 {
+		t.Errorf("http_server_timeouts_total = %v, want 1", v)
+	}
+	if _, ok := seriesValue(app.scrape(), "http_client_cancellations_total", "route", "work"); ok {
+		t.Error("a server timeout was also counted as a client cancellation")
+	}
+}
+
+func TestClientCancellation(t *testing.T) {
+	app := startApp(t, "REGION=ap-south")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, app.url+"/work", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := http.DefaultClient.Do(req); err == nil {
+		resp.Body.Close()
+		t.Fatalf("request completed with %d, want it canceled", resp.StatusCode)
+	}
+
+	span := app.spansNamed("work", 1)[0]
+	if v := span.attr("request.abort_reason"); v != "client_canceled" {
+		t.Errorf("request.abort_reason = %v, want client_canceled", v)
+	}
+	if v := span.attr("http.response.status_code"); v != float64(statusClientClosedRequest) {
+		t.Errorf("http.response.status_code = %v, want 499", v)
+	}
+	if v := app.metricValue("http_client_cancellations_total", "route", "work"); v != 1 {
+		t.Errorf("http_client_cancellations_total = %v, want 1", v)
+	}
+	if _, ok := seriesValue(app.scrape(), "http_server_timeouts_total", "route", "work"); ok {
+		t.Error("a client cancellation was also counted as a server timeout")
+	}
+}
+
+func TestRouteTimeouts(t *testing.T) {
+	app := startApp(t, "REGION=ap-south", "REQUEST_TIMEOUT=1ms", "ROUTE_TIMEOUTS=work=20ms,fanout=5s")
+
+	if resp, _ := app.get("/work"); resp.StatusCode != http.StatusGatewayTimeout {
+		t.Errorf("/work with a 20ms timeout: status = %d, want 504", resp.StatusCode)
+	}
+	if resp, _ := app.get("/fanout?n=3"); resp.StatusCode != http.StatusOK {
+		t.Errorf("/fanout with a 5s timeout: status = %d, want 200", resp.StatusCode)
+	}
+	if v := app.metricValue("http_server_timeouts_total", "route", "work"); v != 1 {
+		t.Errorf("work timeouts = %v, want 1", v)
+	}
+	if _, ok := seriesValue(app.scrape(), "http_server_timeouts_total", "route", "fanout"); ok {
+		t.Error("fanout timed out despite its 5s route timeout")
+	}
+}
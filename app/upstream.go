@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// The upstream always speaks W3C, like most callers would, whatever this
+// app's own OTEL_PROPAGATORS are; a mismatch then shows up as broken traces
+var upstreamPropagator = propagation.NewCompositeTextMapPropagator(
+	propagation.TraceContext{},
+	propagation.Baggage{},
+)
+
+var upstreamClient = &http.Client{Timeout: 10 * time.Second}
+
+// Most upstream requests out at once. A slow or hanging target would
+// otherwise pile up a goroutine per tick until the client timeout.
+const maxUpstreamInFlight = 32
+
+// runUpstream plays a client calling this app: UPSTREAM_RATE times a second
+// it starts a fresh trace with an "upstream" client span, and sends a
+// request carrying its traceparent (and UPSTREAM_BAGGAGE) to target. Every
+// request should show up as one trace, the app's server span a child of
+// the upstream span. Ticks that find maxUpstreamInFlight requests still
+// waiting are skipped.
+func runUpstream(target string) {
+	bag, err := baggage.Parse(cfg.UpstreamBaggage)
+	if err != nil {
+		log.Fatalf("invalid UPSTREAM_BAGGAGE %q: %v", cfg.UpstreamBaggage, err)
+	}
+	slots := make(chan struct{}, maxUpstreamInFlight)
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / cfg.UpstreamRate))
+	defer ticker.Stop()
+	for range ticker.C {
+		select {
+		case slots <- struct{}{}:
+			go func() {
+				defer func() { <-slots }()
+				upstreamRequest(baggage.ContextWithBaggage(context.Background(), bag), target)
+			}()
+		default:
+		}
+	}
+}
+
+func upstreamRequest(ctx context.Context, target string) {
+	ctx, span := otel.Tracer("app").Start(ctx, "upstream GET",
+		trace.WithNewRoot(),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.Bool("app.upstream", true),
+			semconv.HTTPMethod(http.MethodGet),
+			semconv.HTTPURL(target),
+		),
+	)
+	defer span.End()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	upstreamPropagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+	resp, err := upstreamClient.Do(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	span.SetAttributes(semconv.HTTPStatusCode(resp.StatusCode))
+
+	logger.DebugContext(ctx, "upstream request sent",
+		"url", target,
+		"status", resp.StatusCode,
+		"traceparent", req.Header.Get("traceparent"),
+	)
+}
+
+// upstreamTargetURL is UPSTREAM_URL, or /work on the address the server
+// actually listens on
+func upstreamTargetURL(addr net.Addr) string {
+	if cfg.UpstreamURL != "" {
+		return cfg.UpstreamURL
+	}
+	host, port, _ := net.SplitHostPort(addr.String())
+	if ip := net.ParseIP(host); ip == nil || ip.IsUnspecified() {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, port) + "/work"
+}
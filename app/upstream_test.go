@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestUpstreamTraceContinued(t *testing.T) {
+	app := startApp(t, "UPSTREAM_RATE=10")
+	upstream := app.spansNamed("upstream GET", 3)
+	app.spansNamed("work", 3)
+
+	for _, u := range upstream {
+		if u.SpanKind != spanKindClient || u.Parent.SpanID != "0000000000000000" {
+			t.Errorf("upstream span is kind %d with parent %s, want a root client span", u.SpanKind, u.Parent.SpanID)
+		}
+		children := app.children(u)
+		if len(children) != 1 || children[0].Name != "work" || children[0].SpanKind != spanKindServer {
+			t.Errorf("upstream span %s has children %v, want the work server span", u.SpanContext.SpanID, children)
+		}
+	}
+}
+
+func TestUpstreamSendsTraceparentAndBaggage(t *testing.T) {
+	stub := startStub(t, http.StatusOK)
+	app := startApp(t, "UPSTREAM_RATE=10", "UPSTREAM_URL="+stub.URL, "UPSTREAM_BAGGAGE=tenant=acme")
+	u := app.spansNamed("upstream GET", 1)[0]
+
+	want := "00-" + u.SpanContext.TraceID + "-" + u.SpanContext.SpanID + "-01"
+	var sent http.Header
+	for _, h := range stub.requests() {
+		if h.Get("traceparent") == want {
+			sent = h
+		}
+	}
+	if sent == nil {
+		t.Fatalf("no request carried traceparent %s", want)
+	}
+	if got := sent.Get("baggage"); !strings.Contains(got, "tenant=acme") {
+		t.Errorf("baggage = %q, want tenant=acme", got)
+	}
+}
+
+func TestUpstreamInFlightCapped(t *testing.T) {
+	// A target that never answers until the test ends
+	release := make(chan struct{})
+	var inFlight, peak atomic.Int64
+	var mu sync.Mutex
+	hang := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		peak.Store(max(peak.Load(), inFlight.Add(1)))
+		mu.Unlock()
+		<-release
+		inFlight.Add(-1)
+	}))
+	t.Cleanup(hang.Close)
+	t.Cleanup(func() { close(release) })
+
+	app := startApp(t, "UPSTREAM_RATE=1000", "UPSTREAM_URL="+hang.URL)
+	app.waitFor("upstream requests to reach the cap", func() bool {
+		return peak.Load() >= maxUpstreamInFlight
+	})
+	time.Sleep(200 * time.Millisecond)
+	if p := peak.Load(); p != maxUpstreamInFlight {
+		t.Errorf("%d upstream requests out at once, want at most %d", p, maxUpstreamInFlight)
+	}
+}
+
+func TestUpstreamRateBounded(t *testing.T) {
+	app := launchApp(t, "UPSTREAM_RATE=2e9")
+	if code := app.wait(); code == 0 {
+		t.Error("app started with an UPSTREAM_RATE faster than its ticker can run")
+	}
+	app.lineContaining("UPSTREAM_RATE must be between 0 and")
+}
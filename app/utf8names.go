@@ -0,0 +1,33 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+// Copy of the request histogram under OTel's dotted names. Nil unless
+// UTF8_METRIC_NAMES.
+var utf8Duration *prometheus.HistogramVec
+
+// newUTF8Duration switches the Prometheus client to UTF-8 name validation and
+// builds the dotted histogram; NewDesc rejects the name under the legacy
+// scheme, so this has to happen first. Scrapers that send
+// escaping=allow-utf-8 in their Accept header get the names as they are,
+// everyone else gets them escaped (U__http_2e_server_...).
+func newUTF8Duration() *prometheus.HistogramVec {
+	model.NameValidationScheme = model.UTF8Validation
+	return prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http.server.request.duration",
+			Help:    "HTTP request duration seconds, under the OTel semantic convention name",
+			Buckets: reqDurationBuckets,
+		},
+		[]string{"http.request.method", "http.response.status_code"},
+	)
+}
+
+func observeUTF8Duration(method string, status int, d float64) {
+	utf8Duration.WithLabelValues(method, strconv.Itoa(status)).Observe(d)
+}
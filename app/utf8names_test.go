@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUTF8MetricNamesNegotiated(t *testing.T) {
+	app := startApp(t, "UTF8_METRIC_NAMES=true")
+	app.get("/work")
+
+	for _, tc := range []struct {
+		accept, escaping, series string
+	}{
+		{
+			"text/plain;version=1.0.0;escaping=allow-utf-8", "escaping=allow-utf-8",
+			`{"http.server.request.duration_count","http.request.method"="GET","http.response.status_code"="200"} 1`,
+		},
+		{
+			openMetricsAccept + ";escaping=allow-utf-8", "escaping=allow-utf-8",
+			`{"http.server.request.duration_count","http.request.method"="GET","http.response.status_code"="200"} 1`,
+		},
+		// A scraper that doesn't ask for UTF-8 gets the names escaped
+		{
+			"text/plain", "escaping=values",
+			`U__http_2e_server_2e_request_2e_duration_count{U__http_2e_request_2e_method="GET",U__http_2e_response_2e_status_code="200"} 1`,
+		},
+	} {
+		resp, body := app.get("/metrics", "Accept", tc.accept)
+		if ct := resp.Header.Get("Content-Type"); !strings.Contains(ct, tc.escaping) {
+			t.Errorf("Accept %q: Content-Type = %q, want %s", tc.accept, ct, tc.escaping)
+		}
+		if !strings.Contains(body, tc.series) {
+			t.Errorf("Accept %q: scrape lacks %s", tc.accept, tc.series)
+		}
+	}
+}
+
+func TestUTF8MetricNamesOffByDefault(t *testing.T) {
+	app := startApp(t)
+	app.get("/work")
+	_, body := app.get("/metrics", "Accept", "text/plain;version=1.0.0;escaping=allow-utf-8")
+	if strings.Contains(body, "http.server.request.duration") {
+		t.Error("dotted histogram exported without UTF8_METRIC_NAMES")
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Statuses /work answers GET, POST and HEAD with; OPTIONS always gets a 204.
+// 499 is left out: a client hanging up isn't worth a standing series.
+var warmupStatuses = []int{
+	http.StatusOK,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// warmupRequestMetrics creates every label combination of the request
+// metrics /work can produce, so their series exist at zero from start-up
+// instead of appearing with the first matching request. rate() and error
+// ratio panels then show 0 rather than "no data" on a fresh instance.
+func warmupRequestMetrics() {
+	for _, method := range strings.Split(workAllowedMethods, ", ") {
+		if method == http.MethodOptions {
+			// OPTIONS only ever lands in the histogram
+			reqDuration.With(reqLabels(method, http.StatusNoContent))
+			continue
+		}
+		for _, status := range warmupStatuses {
+			labels := reqLabels(method, status)
+			reqDuration.With(labels)
+			if reqSummary != nil {
+				reqSummary.With(labels)
+			}
+			if utf8Duration != nil {
+				utf8Duration.WithLabelValues(method, strconv.Itoa(status))
+			}
+		}
+	}
+}
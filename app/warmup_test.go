@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestMetricsWarmupSeriesAtZero(t *testing.T) {
+	app := startApp(t, "METRICS_WARMUP=true")
+	families := app.scrape()
+
+	want := [][2]string{{"OPTIONS", "204"}}
+	for _, method := range []string{"GET", "POST", "HEAD"} {
+		for _, status := range warmupStatuses {
+			want = append(want, [2]string{method, strconv.Itoa(status)})
+		}
+	}
+	for _, series := range want {
+		v, ok := seriesValue(families, "http_request_duration_seconds", "method", series[0], "status", series[1])
+		if !ok || v != 0 {
+			t.Errorf("request histogram {method=%q,status=%q} = %v (present %v), want a series at 0", series[0], series[1], v, ok)
+		}
+	}
+	if n := len(families["http_request_duration_seconds"].GetMetric()); n != len(want) {
+		t.Errorf("request histogram has %d series at start-up, want %d", n, len(want))
+	}
+}
+
+func TestNoWarmupNoSeries(t *testing.T) {
+	app := startApp(t)
+	if _, ok := seriesValue(app.scrape(), "http_request_duration_seconds"); ok {
+		t.Error("request histogram has series before any request without METRICS_WARMUP")
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"log/slog"
+	"math/rand"
+)
+
+// Warnings emitted by WARN_RATE, independent of whether anything went wrong
+var simulatedWarnings = []struct {
+	msg, reason string
+}{
+	{"elevated latency", "latency_above_baseline"},
+	{"cache degraded", "cache_hit_ratio_low"},
+	{"dependency slow", "downstream_latency_high"},
+}
+
+// maybeWarn emits a simulated warning for WARN_RATE of the calls, giving
+// log-level alerting a controllable stream to fire on
+func maybeWarn(log *slog.Logger) {
+	if rand.Float64() >= cfg.WarnRate {
+		return
+	}
+	w := simulatedWarnings[rand.Intn(len(simulatedWarnings))]
+	log.Warn(w.msg, "reason", w.reason, "simulated", true)
+}
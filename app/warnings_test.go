@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestWarnRateOneWarnsOnEverySuccess(t *testing.T) {
+	app := startApp(t, "WARN_RATE=1")
+
+	const n = 5
+	for range n {
+		if resp, _ := app.get("/work"); resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d, want 200", resp.StatusCode)
+		}
+	}
+
+	app.spansNamed("work", n)
+	succeeded := map[any]bool{}
+	warned := map[any]bool{}
+	for _, rec := range app.logs() {
+		switch {
+		case rec["msg"] == "request succeeded":
+			succeeded[rec["trace_id"]] = true
+		case rec["level"] == "WARN" && rec["simulated"] == true:
+			if rec["reason"] == nil {
+				t.Errorf("warning without a reason: %v", rec)
+			}
+			warned[rec["trace_id"]] = true
+		}
+	}
+	if len(succeeded) != n {
+		t.Fatalf("%d successful requests logged, want %d", len(succeeded), n)
+	}
+	for id := range succeeded {
+		if !warned[id] {
+			t.Errorf("successful request %v emitted no warning", id)
+		}
+	}
+}
+
+func TestWarnRateZeroNeverWarns(t *testing.T) {
+	app := startApp(t, "WARN_RATE=0")
+
+	app.get("/work")
+	app.spansNamed("work", 1)
+	for _, rec := range app.logs() {
+		if rec["simulated"] == true {
+			t.Errorf("simulated warning with WARN_RATE=0: %v", rec)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var poolBusyWorkers = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "background_pool_busy_workers",
+		Help: "Background pool workers currently running a task",
+	},
+)
+
+var poolSize = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "background_pool_workers",
+		Help: "Size of the background worker pool; busy/size is its utilization",
+	},
+)
+
+// workerPool runs background tasks on a fixed set of goroutines, so periodic
+// work like collector checks, watchers and the shutdown flush doesn't spawn
+// fresh goroutines every tick
+type workerPool struct {
+	tasks chan func()
+}
+
+// Shared by background export, flush, watch and health tasks; set up in main
+var backgroundPool *workerPool
+
+func newWorkerPool(workers int) *workerPool {
+	p := &workerPool{tasks: make(chan func(), workers)}
+	poolSize.Set(float64(workers))
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+func (p *workerPool) work() {
+	for task := range p.tasks {
+		poolBusyWorkers.Inc()
+		task()
+		poolBusyWorkers.Dec()
+	}
+}
+
+// run executes every task on the pool and waits for all of them. Submission
+// blocks while the pool and its queue are full, which is the bound.
+func (p *workerPool) run(tasks ...func()) {
+	var wg sync.WaitGroup
+	wg.Add(len(tasks))
+	for _, task := range tasks {
+		p.tasks <- func() {
+			defer wg.Done()
+			task()
+		}
+	}
+	wg.Wait()
+}
+
+// every runs task on the pool once per interval, forever. Ticks that come
+// while the previous run is still going are dropped.
+func (p *workerPool) every(interval time.Duration, task func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		p.run(task)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkerPoolBoundsConcurrency(t *testing.T) {
+	const workers, n = 3, 20
+	p := newWorkerPool(workers)
+
+	var running, peak, done atomic.Int32
+	tasks := make([]func(), n)
+	for i := range tasks {
+		tasks[i] = func() {
+			cur := running.Add(1)
+			for {
+				old := peak.Load()
+				if cur <= old || peak.CompareAndSwap(old, cur) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+			done.Add(1)
+		}
+	}
+	p.run(tasks...)
+
+	if got := done.Load(); got != n {
+		t.Errorf("%d tasks ran, want %d", got, n)
+	}
+	if got := peak.Load(); got > workers {
+		t.Errorf("%d tasks ran at once, want at most %d", got, workers)
+	}
+	if got := peak.Load(); got < 2 {
+		t.Errorf("peak concurrency %d, want the pool to run tasks in parallel", got)
+	}
+}